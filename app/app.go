@@ -1,9 +1,13 @@
 package app
 
 import (
+	"context"
 	"embed"
+	"net/http"
+	"sync"
 
 	"fx.prodigy9.co/cmd"
+	"fx.prodigy9.co/config"
 	"fx.prodigy9.co/data/migrator"
 	"fx.prodigy9.co/httpserver"
 	"fx.prodigy9.co/httpserver/controllers"
@@ -26,17 +30,14 @@ type Interface interface {
 }
 
 func Start(app Interface) error {
-	embedMigrations(app)
+	registerMigrations(app)
 
 	jobs, cmds, fragment := collect(app)
 	if len(jobs) > 0 {
 		cmds = append(cmds, cmd.BuildWorkerCommand(jobs...))
 	}
 	if !fragment.IsEmpty() {
-		if fragment.HasNoMiddlewares() {
-			fragment.AddMiddlewares(middlewares.DefaultForAPI()...)
-		}
-		cmds = append(cmds, cmd.BuildServeCommandFromFragments(fragment))
+		cmds = append(cmds, cmd.BuildServeCommandFromFragments(withDefaultMiddlewares(fragment)))
 	}
 
 	return cmd.
@@ -44,12 +45,63 @@ func Start(app Interface) error {
 		Execute()
 }
 
-func embedMigrations(app Interface) {
+// Fragment collects the HTTP fragment tree of app and its children, the same way Start
+// serves it.
+func Fragment(app Interface) *httpserver.Fragment {
+	_, _, fragment := collect(app)
+	return withDefaultMiddlewares(fragment)
+}
+
+// Handler builds the HTTP handler that `serve` would run for app with cfg, e.g. for
+// fxtest.NewServer.
+func Handler(app Interface, cfg *config.Source) (http.Handler, error) {
+	return httpserver.NewWithFragments(cfg, []*httpserver.Fragment{Fragment(app)}).Handler()
+}
+
+// Migrate applies the migrations of app and its children with migrator.MigrateAuto, as
+// `data migrate` would, e.g. to set up the database of fxtest.ConnectTxDatabase.
+func Migrate(ctx context.Context, app Interface) error {
+	registerMigrations(app)
+	return migrator.MigrateAuto(ctx)
+}
+
+var (
+	registeredMutex sync.Mutex
+	registered      = map[Interface]bool{}
+)
+
+// registerMigrations embeds the migrations of app once, however many times it is started
+// or migrated.
+func registerMigrations(app Interface) {
+	registeredMutex.Lock()
+	defer registeredMutex.Unlock()
+
+	if !registered[app] {
+		registered[app] = true
+		embedMigrations(app, "")
+	}
+}
+
+func withDefaultMiddlewares(fragment *httpserver.Fragment) *httpserver.Fragment {
+	if !fragment.IsEmpty() && fragment.HasNoMiddlewares() {
+		fragment.AddMiddlewares(middlewares.DefaultForAPI()...)
+	}
+	return fragment
+}
+
+// embedMigrations registers the migrations of the app and its fragments. Fragments get
+// their own namespace by name (unnamed ones share their parent's) so that they are
+// tracked and planned separately.
+func embedMigrations(app Interface, namespace string) {
 	if mig := app.EmbeddedMigrations(); mig != nil {
-		migrator.Embed(*mig)
+		migrator.EmbedNamespace(namespace, *mig)
 	}
 	for _, child := range app.Children() {
-		embedMigrations(child)
+		if name := child.Name(); name != "" {
+			embedMigrations(child, name)
+		} else {
+			embedMigrations(child, namespace)
+		}
 	}
 }
 
@@ -15,4 +15,4 @@ import (
 var migrations embed.FS
 
 // App is the mountable fragment carrying the audit_events migration.
-var App = app.Build().EmbedMigrations(migrations)
+var App = app.Build().Name("audit").EmbedMigrations(migrations)
@@ -1,10 +1,14 @@
 package app
 
 import (
+	"context"
 	"embed"
+	"net/http"
 
 	"fx.prodigy9.co/cmd"
 	"fx.prodigy9.co/cmd/data"
+	"fx.prodigy9.co/config"
+	"fx.prodigy9.co/httpserver"
 	"fx.prodigy9.co/httpserver/controllers"
 	"fx.prodigy9.co/httpserver/middlewares"
 	"fx.prodigy9.co/worker"
@@ -18,6 +22,15 @@ type Builder struct {
 func Build() *Builder           { return &Builder{} }
 func (b *Builder) Start() error { return Start(&b.appImpl) }
 
+// Fragment returns the HTTP fragment tree of the app and its mounted fragments, as served
+// by Start.
+func (b *Builder) Fragment() *httpserver.Fragment { return Fragment(&b.appImpl) }
+
+func (b *Builder) Handler(cfg *config.Source) (http.Handler, error) {
+	return Handler(&b.appImpl, cfg)
+}
+func (b *Builder) Migrate(ctx context.Context) error { return Migrate(ctx, &b.appImpl) }
+
 func (b *Builder) AddDefaults() *Builder {
 	return b.AddDefaultMiddlewares().
 		AddDefaultCommands()
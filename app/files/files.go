@@ -27,6 +27,7 @@ var App = NewApp(nil)
 func NewApp(client *blobstore.Client) *app.Builder {
 	_ = client // stored for reference; controllers receive client via WithClient option
 	return app.Build().
+		Name("files").
 		EmbedMigrations(migrations)
 }
 
@@ -12,7 +12,7 @@ type Provider struct {
 	db *sqlx.DB
 }
 
-var _ config.Provider = &Provider{}
+var _ config.NamedProvider = &Provider{}
 
 func NewProvider(ctx context.Context) (*Provider, error) {
 	cfg := config.FromContext(ctx)
@@ -32,6 +32,8 @@ func NewProvider(ctx context.Context) (*Provider, error) {
 	return &Provider{db}, nil
 }
 
+func (p *Provider) Name() string { return "settings" }
+
 func (p *Provider) Initialize() error {
 	return p.db.Ping()
 }
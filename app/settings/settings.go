@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"fx.prodigy9.co/app"
+	"fx.prodigy9.co/clock"
 	"fx.prodigy9.co/data"
 )
 
@@ -13,6 +14,7 @@ import (
 var migrations embed.FS
 
 var App = app.Build().
+	Name("settings").
 	EmbedMigrations(migrations).
 	Controllers(Ctr{})
 
@@ -67,7 +69,7 @@ func Set(ctx context.Context, key string, value string) (*Settings, error) {
 	`
 
 	settings := &Settings{}
-	if err := data.Get(ctx, settings, sql, key, value, time.Now()); err != nil {
+	if err := data.Get(ctx, settings, sql, key, value, clock.Now(ctx)); err != nil {
 		return nil, err
 	} else {
 		return settings, nil
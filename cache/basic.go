@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"fx.prodigy9.co/clock"
 )
 
 type basic[T any] struct {
@@ -17,26 +19,26 @@ func Basic[T any]() Interface[T] {
 	return &basic[T]{}
 }
 
-func (c *basic[T]) Get(_ context.Context, initer Initializer[T]) (T, error) {
-	if data, ok := c.get(); ok {
+func (c *basic[T]) Get(ctx context.Context, initer Initializer[T]) (T, error) {
+	if data, ok := c.get(ctx); ok {
 		return data, nil
 	} else {
-		return c.initialize(initer)
+		return c.initialize(ctx, initer)
 	}
 }
 
-func (c *basic[T]) Invalidate(_ context.Context) error {
+func (c *basic[T]) Invalidate(ctx context.Context) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	c.expires = time.Now()
+	c.expires = clock.Now(ctx)
 	return nil
 }
 
-func (c *basic[T]) get() (result T, ok bool) {
+func (c *basic[T]) get(ctx context.Context) (result T, ok bool) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
-	if time.Now().Before(c.expires) {
+	if clock.Now(ctx).Before(c.expires) {
 		result, ok = c.data, true
 	} else {
 		ok = false
@@ -44,7 +46,7 @@ func (c *basic[T]) get() (result T, ok bool) {
 	return
 }
 
-func (c *basic[T]) initialize(initer Initializer[T]) (result T, err error) {
+func (c *basic[T]) initialize(ctx context.Context, initer Initializer[T]) (result T, err error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
@@ -54,6 +56,6 @@ func (c *basic[T]) initialize(initer Initializer[T]) (result T, err error) {
 	}
 
 	c.data = result
-	c.expires = time.Now().Add(age)
+	c.expires = clock.Now(ctx).Add(age)
 	return
 }
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fx.prodigy9.co/fxtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBasic_Expiry(t *testing.T) {
+	ctx, clock := fxtest.WithClock(context.Background(), time.Time{})
+
+	calls := 0
+	initer := func() (int, time.Duration, error) {
+		calls += 1
+		return calls, time.Minute, nil
+	}
+
+	cache := Basic[int]()
+	get := func() int {
+		value, err := cache.Get(ctx, initer)
+		require.NoError(t, err)
+		return value
+	}
+
+	require.Equal(t, 1, get())
+	clock.Advance(59 * time.Second)
+	require.Equal(t, 1, get())
+	clock.Advance(time.Second)
+	require.Equal(t, 2, get())
+
+	require.NoError(t, cache.Invalidate(ctx))
+	require.Equal(t, 3, get())
+}
@@ -0,0 +1,54 @@
+// Package clock lets code read the current time from its context instead of calling
+// time.Now directly, so tests can substitute a fake clock, see fxtest.NewClock.
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+
+	// After is time.After on the clock, the channel receives the clock's time once d has
+	// passed on it.
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+// Real is the system clock, used when the context carries no other clock.
+var Real Clock = realClock{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, clock Clock) context.Context {
+	if clock == nil {
+		return ctx
+	} else {
+		return context.WithValue(ctx, contextKey{}, clock)
+	}
+}
+
+func FromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(contextKey{}).(Clock); ok {
+		return clock
+	} else {
+		return Real
+	}
+}
+
+// IsSet reports whether ctx carries a clock of its own rather than falling back to Real.
+func IsSet(ctx context.Context) bool {
+	_, ok := ctx.Value(contextKey{}).(Clock)
+	return ok
+}
+
+// Now returns the current time on the clock in ctx.
+func Now(ctx context.Context) time.Time { return FromContext(ctx).Now() }
+
+// Since is time.Since on the clock in ctx.
+func Since(ctx context.Context, t time.Time) time.Duration { return Now(ctx).Sub(t) }
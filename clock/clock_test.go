@@ -0,0 +1,28 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time                         { return time.Time(c) }
+func (c fixedClock) After(d time.Duration) <-chan time.Time { return nil }
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, Real, FromContext(ctx))
+	require.False(t, IsSet(ctx))
+	require.WithinDuration(t, time.Now(), Now(ctx), time.Second)
+	require.Equal(t, ctx, NewContext(ctx, nil))
+
+	at := time.Date(2025, time.April, 1, 17, 19, 0, 0, time.UTC)
+	ctx = NewContext(ctx, fixedClock(at))
+	require.True(t, IsSet(ctx))
+	require.Equal(t, at, Now(ctx))
+	require.Equal(t, time.Hour, Since(ctx, at.Add(-time.Hour)))
+}
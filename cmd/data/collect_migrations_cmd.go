@@ -31,6 +31,11 @@ func runCollectMigrationsCmd(cmd *cobra.Command, args []string) {
 	}
 
 	for _, migration := range migrations {
+		if migration.IsGo() {
+			fxlog.Log("skip go migration", fxlog.String("name", migration.Name))
+			continue
+		}
+
 		upPath := filepath.Join(outdir, migration.Name+migrator.UpExt)
 		downPath := filepath.Join(outdir, migration.Name+migrator.DownExt)
 
@@ -18,5 +18,9 @@ func init() {
 		rollbackCmd,
 		listMigrationsCmd,
 		resyncMigrationsCmd,
+		migrationStatusCmd,
+		squashMigrationsCmd,
+		lintMigrationsCmd,
+		seedCmd,
 	)
 }
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fx.prodigy9.co/cmd/cmdutil"
+	"fx.prodigy9.co/data/migrator"
+	"fx.prodigy9.co/fxlog"
+	"github.com/spf13/cobra"
+)
+
+var lintMigrationsCmd = &cobra.Command{
+	Use:   "lint-migrations",
+	Short: "Check pending migrations for operations that are risky on live databases.",
+	Run:   runLintMigrationsCmd,
+}
+
+var lintAll bool
+
+func init() {
+	lintMigrationsCmd.Flags().BoolVar(&lintAll, "all", false,
+		"lint every migration file instead of only pending ones, does not need a database")
+}
+
+func runLintMigrationsCmd(cmd *cobra.Command, args []string) {
+	var migrations []migrator.Migration
+	if lintAll {
+		_, cfg := cmdutil.NewBasicContext()
+		all, err := migrator.LoadAuto(cfg)
+		if err != nil {
+			fxlog.Fatalf("lint-migrations: %w", err)
+		}
+		migrations = all
+
+	} else {
+		ctx, mig := cmdutil.NewMigratorContext()
+		plans, _, err := mig.Plan(ctx, migrator.IntentMigrate)
+		if err != nil {
+			fxlog.Fatalf("lint-migrations: %w", err)
+		}
+		for _, plan := range plans {
+			if plan.Action == migrator.ActionMigrate {
+				migrations = append(migrations, plan.Migration)
+			}
+		}
+	}
+
+	errors, warnings := 0, 0
+	for _, migration := range migrations {
+		for _, finding := range migrator.Lint(migration) {
+			finding.Migration = filepath.Join(migration.Dir, migration.Name+migrator.UpExt)
+			if finding.Rule == migrator.RuleEmptyDown {
+				finding.Migration = filepath.Join(migration.Dir, migration.Name+migrator.DownExt)
+			}
+			fmt.Println(finding)
+
+			if finding.Severity == migrator.SeverityError {
+				errors += 1
+			} else {
+				warnings += 1
+			}
+		}
+	}
+
+	fxlog.Log("linted migrations",
+		fxlog.Int("migrations", len(migrations)),
+		fxlog.Int("errors", errors),
+		fxlog.Int("warnings", warnings))
+	if errors > 0 {
+		fxlog.Fatalf("lint-migrations: %d error(s) found", errors)
+	}
+}
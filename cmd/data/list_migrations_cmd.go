@@ -24,6 +24,11 @@ func runListMigrationsCmd(cmd *cobra.Command, args []string) {
 	}
 
 	for _, migration := range migrations {
+		if migration.IsGo() {
+			fmt.Println(filepath.Join(migration.Dir, migration.Name) + " (go)")
+			continue
+		}
+
 		upPath := filepath.Join(migration.Dir, migration.Name+migrator.UpExt)
 		downPath := filepath.Join(migration.Dir, migration.Name+migrator.DownExt)
 		fmt.Println(upPath)
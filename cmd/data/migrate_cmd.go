@@ -1,10 +1,14 @@
 package data
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
 
 	"fx.prodigy9.co/cmd/cmdutil"
 	"fx.prodigy9.co/cmd/prompts"
+	"fx.prodigy9.co/config"
 	"fx.prodigy9.co/data"
 	"fx.prodigy9.co/data/migrator"
 	"fx.prodigy9.co/errutil"
@@ -14,11 +18,32 @@ import (
 )
 
 var migrateCmd = &cobra.Command{
-	Use:   "migrate",
-	Short: "Runs all migration scripts in the configured migrations dir.",
+	Use:   "migrate [target]",
+	Short: "Runs all migration scripts in the configured migrations dir, or up to the given target.",
 	Run:   runMigrateCmd,
 }
 
+var (
+	dryRun       bool
+	dryRunOutput string
+	namespace    string
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{migrateCmd, rollbackCmd, resyncMigrationsCmd} {
+		cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the SQL that would be executed instead of running it")
+		cmd.Flags().StringVarP(&dryRunOutput, "output", "o", "", "write the --dry-run SQL to this file instead of stdout")
+		cmd.Flags().StringVar(&namespace, "namespace", "", "only plan migrations of the app fragment with this name")
+	}
+}
+
+func inNamespace(mig *migrator.Migrator) *migrator.Migrator {
+	if namespace == "" {
+		return mig
+	}
+	return mig.InNamespace(namespace)
+}
+
 func runMigrateCmd(cmd *cobra.Command, args []string) {
 	if err := runMigration(migrator.IntentMigrate, args); err != nil {
 		fxlog.Fatalf("migrate: %w", err)
@@ -31,47 +56,100 @@ func runMigration(intent migrator.Intent, args []string) (err error) {
 	var (
 		ctx, cfg = cmdutil.NewBasicContext()
 		prompt   = prompts.New(cfg, args)
+		target   = prompt.OptionalStr("target migration name or count", "")
 	)
 
-	db, err := data.Connect(cfg)
-	if err != nil {
-		return err
+	if target != "" {
+		switch intent {
+		case migrator.IntentMigrate:
+			intent = migrator.IntentMigrateTo
+		case migrator.IntentRollback:
+			intent = migrator.IntentRollbackTo
+		}
 	}
 
-	migrator := migrator.New(db, migrator.FromAuto(cfg))
-	plans, dirty, err := migrator.Plan(ctx, intent)
+	db, err := data.Connect(cfg)
 	if err != nil {
 		return err
 	}
 
-	if len(plans) == 0 {
-		fxlog.Log("no changes")
+	var (
+		timeout = config.Get(cfg, migrator.LockTimeoutConfig)
+		mig     = inNamespace(migrator.New(db, migrator.FromAuto(cfg)))
+	)
+	run := func(ctx context.Context) error {
+		plans, dirty, err := mig.PlanTo(ctx, intent, target)
+		if err != nil {
+			return err
+		}
+
+		if len(plans) == 0 {
+			fxlog.Log("no changes")
+			return nil
+		}
+
+		printPlans(plans)
+		if dryRun {
+			return writeDryRun(plans)
+		}
+
+		if dirty {
+			fxlog.Log("migrations are missing or have changed content")
+			if !prompt.YesNo("proceed with dirty migrations") {
+				return nil
+			}
+		}
+
+		fxlog.Log("migrations planned", fxlog.Int("migrations", len(plans)))
+		if !prompt.YesNo("apply migrations") {
+			return nil
+		}
+
+		for _, plan := range plans {
+			fmt.Println(plan)
+			if err := mig.Apply(ctx, plan); err != nil {
+				return err
+			}
+		}
+
+		fxlog.Log("migration(s) applied", fxlog.Int("migrations", len(plans)))
 		return nil
 	}
 
+	// dry runs only read the database, no need to wait for other migrators
+	if dryRun {
+		return run(ctx)
+	}
+	return mig.Lock(ctx, timeout, run)
+}
+
+// printPlans lists plans before they are applied. A dry run writing its SQL to stdout
+// gets the list on stderr instead, so the output stays a runnable script.
+func printPlans(plans []migrator.Plan) {
+	var out io.Writer = os.Stdout
+	if dryRun && dryRunOutput == "" {
+		out = os.Stderr
+	}
 	for _, plan := range plans {
-		fmt.Println(plan)
+		fmt.Fprintln(out, plan)
 	}
+}
 
-	if dirty {
-		fxlog.Log("migrations are missing or have changed content")
-		if !prompt.YesNo("proceed with dirty migrations") {
-			return nil
-		}
+func writeDryRun(plans []migrator.Plan) error {
+	if dryRunOutput == "" {
+		return migrator.WriteScript(os.Stdout, plans)
 	}
 
-	fxlog.Log("migrations planned", fxlog.Int("migrations", len(plans)))
-	if !prompt.YesNo("apply migrations") {
-		return nil
+	file, err := os.Create(dryRunOutput)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
-	for _, plan := range plans {
-		fmt.Println(plan)
-		if err = migrator.Apply(ctx, plan); err != nil {
-			return err
-		}
+	if err := migrator.WriteScript(file, plans); err != nil {
+		return err
 	}
 
-	fxlog.Log("migration(s) applied", fxlog.Int("migrations", len(plans)))
-	return nil
+	fxlog.Log("dry run written", fxlog.String("path", dryRunOutput))
+	return file.Close()
 }
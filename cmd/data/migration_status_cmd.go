@@ -0,0 +1,52 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"fx.prodigy9.co/cmd/cmdutil"
+	"fx.prodigy9.co/fxlog"
+	"github.com/spf13/cobra"
+)
+
+var migrationStatusCmd = &cobra.Command{
+	Use:   "migration-status",
+	Short: "Compare migration files against the migrations applied to the database.",
+	Run:   runMigrationStatusCmd,
+}
+
+func runMigrationStatusCmd(cmd *cobra.Command, args []string) {
+	ctx, mig := cmdutil.NewMigratorContext()
+
+	statuses, err := mig.Status(ctx)
+	if err != nil {
+		fxlog.Fatalf("migration-status: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tMIGRATION\tAPPLIED AT\tAPPLIED BY")
+	for _, status := range statuses {
+		appliedAt, appliedBy := "-", "-"
+		if status.Applied != nil {
+			if status.Applied.AppliedAt != nil {
+				appliedAt = status.Applied.AppliedAt.Local().Format(time.DateTime)
+			}
+			if status.Applied.AppliedBy != "" {
+				appliedBy = status.Applied.AppliedBy
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", status.State, status.Name, appliedAt, appliedBy)
+	}
+	if err := tw.Flush(); err != nil {
+		fxlog.Fatalf("migration-status: %w", err)
+	}
+
+	for _, status := range statuses {
+		if diff := status.Diff(); diff != "" {
+			fmt.Println()
+			fmt.Print(diff)
+		}
+	}
+}
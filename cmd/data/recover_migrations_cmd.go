@@ -45,6 +45,11 @@ func runRecoverMigrationsCmd(cmd *cobra.Command, args []string) {
 	}
 
 	for _, migration := range migrations {
+		if migration.IsGo() {
+			fxlog.Log("skip go migration", fxlog.String("name", migration.Name))
+			continue
+		}
+
 		upfile := filepath.Join(outdir, migration.Name+migrator.UpExt)
 		fmt.Fprintln(os.Stdout, upfile)
 		if err := os.WriteFile(upfile, []byte(migration.UpSQL), 0644); err != nil {
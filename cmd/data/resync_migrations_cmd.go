@@ -1,6 +1,8 @@
 package data
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"fx.prodigy9.co/cmd/cmdutil"
@@ -30,34 +32,49 @@ func runResyncMigrationsCmd(cmd *cobra.Command, args []string) {
 		prompt   = prompts.New(config.FromContext(ctx), args)
 	)
 
-	plans, dirty, err := mig.Plan(ctx, migrator.IntentResync)
-	if err != nil {
-		fxlog.Fatalf("resync-migrations: %w", err)
-		return
-	} else if !dirty {
-		fxlog.Log("migrations up-to-date")
-		return
-	}
+	mig = inNamespace(mig)
+	run := func(ctx context.Context) error {
+		plans, dirty, err := mig.Plan(ctx, migrator.IntentResync)
+		if err != nil {
+			return err
+		} else if !dirty {
+			fxlog.Log("migrations up-to-date")
+			return nil
+		}
 
-	// assert(dirty)
-	for _, plan := range plans {
-		fmt.Println(plan)
-	}
-	fxlog.Log("migrations changed", fxlog.Int("migrations", len(plans)))
+		// assert(dirty)
+		printPlans(plans)
+		fxlog.Log("migrations changed", fxlog.Int("migrations", len(plans)))
 
-	if !forceResync {
-		fxlog.Fatalf("dangerous operation, --force is required")
-	}
-	if !prompt.YesNo("re-synchronize migration content") {
-		return
-	}
+		if dryRun {
+			return writeDryRun(plans)
+		}
+		if !forceResync {
+			return errors.New("dangerous operation, --force is required")
+		}
+		if !prompt.YesNo("re-synchronize migration content") {
+			return nil
+		}
 
-	for _, plan := range plans {
-		fmt.Println(plan)
-		if err := mig.Apply(ctx, plan); err != nil {
-			fxlog.Fatalf("resync-migrations: %w", err)
+		for _, plan := range plans {
+			fmt.Println(plan)
+			if err := mig.Apply(ctx, plan); err != nil {
+				return err
+			}
 		}
+
+		fxlog.Log("migration(s) synchronized", fxlog.Int("migrations", len(plans)))
+		return nil
 	}
 
-	fxlog.Log("migration(s) synchronized", fxlog.Int("migrations", len(plans)))
+	var err error
+	if dryRun {
+		err = run(ctx)
+	} else {
+		timeout := config.Get(config.FromContext(ctx), migrator.LockTimeoutConfig)
+		err = mig.Lock(ctx, timeout, run)
+	}
+	if err != nil {
+		fxlog.Fatalf("resync-migrations: %w", err)
+	}
 }
@@ -2,16 +2,19 @@ package data
 
 import (
 	"fx.prodigy9.co/data/migrator"
+	"fx.prodigy9.co/fxlog"
 
 	"github.com/spf13/cobra"
 )
 
 var rollbackCmd = &cobra.Command{
-	Use:   "rollback",
-	Short: "Revert one previously ran migration.",
+	Use:   "rollback [target]",
+	Short: "Revert one previously ran migration, or all migrations after the given target.",
 	Run:   runRollbackCmd,
 }
 
 func runRollbackCmd(cmd *cobra.Command, args []string) {
-	runMigration(migrator.IntentRollback, args)
+	if err := runMigration(migrator.IntentRollback, args); err != nil {
+		fxlog.Fatalf("rollback: %w", err)
+	}
 }
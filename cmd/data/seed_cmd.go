@@ -0,0 +1,58 @@
+package data
+
+import (
+	"fmt"
+
+	"fx.prodigy9.co/cmd/cmdutil"
+	"fx.prodigy9.co/config"
+	"fx.prodigy9.co/data/seed"
+	"fx.prodigy9.co/fxlog"
+	"github.com/spf13/cobra"
+)
+
+var seedCmd = &cobra.Command{
+	Use:   "seed [name...]",
+	Short: "Load seed data for the current environment, or only the named seeds.",
+	Run:   runSeedCmd,
+}
+
+var (
+	seedEnv  string
+	seedList bool
+)
+
+func init() {
+	seedCmd.Flags().StringVar(&seedEnv, "env", "", "environment to seed, overrides ENVIRONMENT")
+	seedCmd.Flags().BoolVar(&seedList, "list", false, "list seeds instead of running them")
+}
+
+func runSeedCmd(cmd *cobra.Command, args []string) {
+	if seedList {
+		_, cfg := cmdutil.NewBasicContext()
+		seeds, err := seed.LoadAuto(cfg)
+		if err != nil {
+			fxlog.Fatalf("seed: %w", err)
+		}
+		for _, s := range seeds {
+			fmt.Println(s)
+		}
+		return
+	}
+
+	ctx, _ := cmdutil.NewDataContext()
+	cfg := config.FromContext(ctx)
+	if seedEnv != "" {
+		config.Set(cfg, seed.EnvironmentConfig, seedEnv)
+	}
+
+	seeds, err := seed.Run(ctx, args...)
+	if err != nil {
+		fxlog.Fatalf("seed: %w", err)
+	}
+	for _, s := range seeds {
+		fxlog.Log("seeded", fxlog.String("seed", s.Name))
+	}
+	fxlog.Log("seeding done",
+		fxlog.String("environment", config.Get(cfg, seed.EnvironmentConfig)),
+		fxlog.Int("seeds", len(seeds)))
+}
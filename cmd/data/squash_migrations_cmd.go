@@ -0,0 +1,68 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fx.prodigy9.co/cmd/cmdutil"
+	"fx.prodigy9.co/cmd/prompts"
+	"fx.prodigy9.co/config"
+	"fx.prodigy9.co/data"
+	"fx.prodigy9.co/data/migrator"
+	"fx.prodigy9.co/fxlog"
+	"github.com/spf13/cobra"
+)
+
+var squashMigrationsCmd = &cobra.Command{
+	Use:   "squash-migrations [dir]",
+	Short: "Replace all applied migrations with a baseline schema dump.",
+	Run:   runSquashMigrationsCmd,
+}
+
+func runSquashMigrationsCmd(cmd *cobra.Command, args []string) {
+	var (
+		ctx, mig = cmdutil.NewMigratorContext()
+		cfg      = config.FromContext(ctx)
+		prompt   = prompts.New(cfg, args)
+		dir      = prompt.OptionalStr("output dir", config.Get(cfg, migrator.MigrationPathConfig))
+		timeout  = config.Get(cfg, migrator.LockTimeoutConfig)
+	)
+
+	err := mig.Lock(ctx, timeout, func(ctx context.Context) error {
+		uppath, downpath, err := migrator.MigrationPath(dir, migrator.BaselineName)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(filepath.Base(uppath), migrator.UpExt)
+		baseline, err := mig.Squash(ctx, config.Get(cfg, data.DatabaseURLConfig), name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(uppath)
+		fmt.Println(downpath)
+		fxlog.Log("migrations to squash", fxlog.Int("migrations", len(baseline.Supersedes())))
+		if !prompt.YesNo("write baseline and mark migrations as superseded") {
+			return nil
+		}
+
+		if err := os.WriteFile(uppath, []byte(baseline.UpSQL), 0644); err != nil {
+			return err
+		} else if err := os.WriteFile(downpath, []byte(baseline.DownSQL), 0644); err != nil {
+			return err
+		} else if err := mig.Apply(ctx, migrator.Plan{Action: migrator.ActionBaseline, Migration: baseline}); err != nil {
+			return err
+		}
+
+		fxlog.Log("migrations squashed, their files can be removed once every database has the baseline",
+			fxlog.String("baseline", name))
+		return nil
+	})
+	if err != nil {
+		fxlog.Fatalf("squash-migrations: %w", err)
+	}
+}
@@ -15,11 +15,22 @@ var PrintConfigCmd = &cobra.Command{
 	RunE:  runPrintConfigCmd,
 }
 
+var printConfigOrigin bool
+
+func init() {
+	PrintConfigCmd.Flags().BoolVar(&printConfigOrigin, "origin", false,
+		"Also print which provider supplied each value.")
+}
+
 func runPrintConfigCmd(cmd *cobra.Command, args []string) error {
 	cfg := config.Configure()
 	if len(args) == 0 {
 		for _, v := range cfg.Vars() {
-			fmt.Fprintln(os.Stdout, v.Name(), "=", config.GetAny(cfg, v))
+			if printConfigOrigin {
+				fmt.Fprintln(os.Stdout, v.Name(), "=", config.GetAny(cfg, v), "("+config.Origin(cfg, v)+")")
+			} else {
+				fmt.Fprintln(os.Stdout, v.Name(), "=", config.GetAny(cfg, v))
+			}
 		}
 
 	} else {
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ExplicitProvider is implemented by providers that can tell values that were set on
+// purpose apart from missing ones. An explicit empty value stops a ChainProvider lookup
+// instead of falling through to the next provider.
+type ExplicitProvider interface {
+	Provider
+	Explicit(name string) bool
+}
+
+// NamedProvider is implemented by providers that have a human-readable name, which is
+// used when reporting where a value came from.
+type NamedProvider interface {
+	Provider
+	Name() string
+}
+
+// ChainProvider resolves names through several providers in order, the first provider
+// with a non-empty value wins. Create one with Chain.
+type ChainProvider struct {
+	providers []Provider
+}
+
+var _ Provider = &ChainProvider{}
+
+// Chain creates a Provider that looks names up in each of providers in turn, highest
+// precedence first, e.g. CLI flags, then the environment, then the settings database:
+//
+//	config.SetDefaultProvider(config.Chain(
+//		config.Named("flags", flags),
+//		config.EnvProvider{},
+//		settingsProvider,
+//	))
+//
+// Empty values fall through to the next provider, unless the provider reports them as
+// explicit, see ExplicitProvider: setting a name to "" in a MemProvider hides it from the
+// rest of the chain. Set writes to the first provider only, so values set at runtime
+// take precedence over everything else in the chain.
+func Chain(providers ...Provider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+func (c *ChainProvider) Providers() []Provider {
+	return c.providers
+}
+
+func (c *ChainProvider) Initialize() error {
+	var errs []error
+	for _, p := range c.providers {
+		if err := p.Initialize(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ProviderName(p), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *ChainProvider) Get(name string) (string, bool, error) {
+	val, _, ok, err := c.Lookup(name)
+	return val, ok, err
+}
+
+// Lookup is like Get but also returns the provider in the chain that supplied the value.
+func (c *ChainProvider) Lookup(name string) (string, Provider, bool, error) {
+	for _, p := range c.providers {
+		if val, ok, err := p.Get(name); err != nil {
+			return "", nil, false, fmt.Errorf("%s: %w", ProviderName(p), err)
+		} else if ok && (strings.TrimSpace(val) != "" || isExplicit(p, name)) {
+			return val, p, true, nil
+		}
+	}
+	return "", nil, false, nil
+}
+
+func isExplicit(p Provider, name string) bool {
+	if named, ok := p.(namedProvider); ok {
+		p = named.Provider
+	}
+	explicit, ok := p.(ExplicitProvider)
+	return ok && explicit.Explicit(name)
+}
+
+func (c *ChainProvider) Set(name string, val string) error {
+	if len(c.providers) == 0 {
+		return errors.New("config: empty provider chain")
+	}
+	return c.providers[0].Set(name, val)
+}
+
+// Named wraps p so that it reports name as its origin, e.g. to tell apart two providers
+// of the same type in a chain.
+func Named(name string, p Provider) NamedProvider {
+	return namedProvider{Provider: p, name: name}
+}
+
+type namedProvider struct {
+	Provider
+	name string
+}
+
+func (p namedProvider) Name() string { return p.name }
+
+// ProviderName returns the name of a NamedProvider, or its type otherwise.
+func ProviderName(p Provider) string {
+	if named, ok := p.(NamedProvider); ok {
+		return named.Name()
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", p), "*")
+}
+
+// Origin reports where the value GetAny returns for v comes from: the name of the
+// provider that supplied it, or "default" if the variable falls back to its default
+// value. With a ChainProvider, the provider within the chain is named.
+func Origin(src *Source, v _Var) string {
+	var (
+		provider = src.provider
+		raw      string
+		ok       bool
+		err      error
+	)
+	if chain, isChain := provider.(*ChainProvider); isChain {
+		raw, provider, ok, err = chain.Lookup(v.Name())
+	} else {
+		raw, ok, err = provider.Get(v.Name())
+	}
+
+	raw = strings.TrimSpace(raw)
+	if err != nil || !ok || raw == "" {
+		return "default"
+	} else if _, err := v.parseAny(raw); err != nil {
+		return "default"
+	} else {
+		return ProviderName(provider)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	var (
+		flags    = &FileProvider{}
+		settings = &MemProvider{}
+		chain    = Chain(Named("flags", flags), settings)
+
+		portVar  = IntDef("TEST_CHAIN_PORT", 3000)
+		hostVar  = StrDef("TEST_CHAIN_HOST", "localhost")
+		debugVar = Bool("TEST_CHAIN_DEBUG")
+	)
+	src := NewSource(chain, []_Var{portVar, hostVar, debugVar})
+
+	require.NoError(t, settings.Set("TEST_CHAIN_PORT", "4000"))
+	require.NoError(t, settings.Set("TEST_CHAIN_HOST", "db.internal"))
+	require.NoError(t, flags.Set("TEST_CHAIN_HOST", "example.com"))
+	require.NoError(t, flags.Set("TEST_CHAIN_PORT", " ")) // empty falls through
+	require.NoError(t, settings.Set("TEST_CHAIN_DEBUG", "not-a-bool"))
+
+	require.Equal(t, 4000, Get(src, portVar))
+	require.Equal(t, "example.com", Get(src, hostVar))
+	require.False(t, Get(src, debugVar))
+
+	require.Equal(t, "memory", Origin(src, portVar))
+	require.Equal(t, "flags", Origin(src, hostVar))
+	require.Equal(t, "default", Origin(src, debugVar))
+
+	Set(src, portVar, 5000)
+	val, ok, err := flags.Get("TEST_CHAIN_PORT")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "5000", val)
+	require.Equal(t, "flags", Origin(src, portVar))
+}
+
+func TestChain_EmptyMemValue(t *testing.T) {
+	t.Setenv("TEST_CHAIN_NAME", "from-env")
+
+	var (
+		mem     = &MemProvider{}
+		chain   = Chain(mem, EnvProvider{})
+		nameVar = StrDef("TEST_CHAIN_NAME", "default-name")
+	)
+	src := NewSource(chain, []_Var{nameVar})
+	require.Equal(t, "from-env", Get(src, nameVar))
+	require.Equal(t, "env", Origin(src, nameVar))
+
+	// clearing a value in memory does not fall back to the environment
+	require.NoError(t, mem.Set("TEST_CHAIN_NAME", ""))
+	require.Equal(t, "default-name", Get(src, nameVar))
+	require.Equal(t, "default", Origin(src, nameVar))
+}
+
+type explicitProvider struct {
+	MemProvider
+	explicit string
+}
+
+func (p *explicitProvider) Explicit(name string) bool { return name == p.explicit }
+
+func TestChain_ExplicitProvider(t *testing.T) {
+	var (
+		first  = &explicitProvider{explicit: "TEST_EXPLICIT_A"}
+		second = &MemProvider{}
+		chain  = Chain(Named("first", first), second)
+	)
+	require.NoError(t, chain.Initialize())
+	for _, name := range []string{"TEST_EXPLICIT_A", "TEST_EXPLICIT_B"} {
+		require.NoError(t, first.Set(name, ""))
+		require.NoError(t, second.Set(name, "second"))
+	}
+
+	val, p, ok, err := chain.Lookup("TEST_EXPLICIT_A")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "", val)
+	require.Equal(t, "first", ProviderName(p))
+
+	val, p, ok, err = chain.Lookup("TEST_EXPLICIT_B")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "second", val)
+	require.Same(t, second, p)
+}
+
+func TestOrigin_SingleProvider(t *testing.T) {
+	hostVar := Str("TEST_ORIGIN_HOST")
+	src := NewSource(&MemProvider{}, []_Var{hostVar})
+	require.Equal(t, "default", Origin(src, hostVar))
+
+	Set(src, hostVar, "example.com")
+	require.Equal(t, "memory", Origin(src, hostVar))
+}
+
+func TestProviderName(t *testing.T) {
+	require.Equal(t, "env", ProviderName(EnvProvider{}))
+	require.Equal(t, "cli", ProviderName(Named("cli", EnvProvider{})))
+	require.Equal(t, "config.ChainProvider", ProviderName(Chain()))
+	require.Error(t, Chain().Set("X", "y"))
+}
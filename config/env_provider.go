@@ -13,7 +13,9 @@ import (
 
 type EnvProvider struct{}
 
-var _ Provider = EnvProvider{}
+var _ NamedProvider = EnvProvider{}
+
+func (p EnvProvider) Name() string { return "env" }
 
 func (p EnvProvider) Initialize() error {
 	envs, err := findDotEnvs()
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv names the environment variable FileProvider reads its path from when
+// Path is not set.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// FileProvider loads values from a TOML, YAML or JSON file, picked by file extension.
+// Nested keys map to variable names by joining them with underscores and upper-casing,
+// so `database.url` in the file supplies DATABASE_URL:
+//
+//	[database]
+//	url = "postgres:///mydb"
+//	max_idle = 2
+//
+// Lists of plain values become comma-separated strings, other lists and objects inside
+// lists are kept as JSON. Without a path, the provider is empty.
+//
+// Values are read once in Initialize. Set only changes the in-memory copy, the file is
+// never written. To let environment variables override the file, chain the two:
+//
+//	config.SetDefaultProvider(config.Chain(config.EnvProvider{}, &config.FileProvider{}))
+type FileProvider struct {
+	// Path of the file to load, the value of CONFIG_FILE if empty.
+	Path string
+
+	values map[string]string
+}
+
+var _ NamedProvider = &FileProvider{}
+
+func (p *FileProvider) Name() string { return "file" }
+
+func (p *FileProvider) Initialize() error {
+	p.values = map[string]string{}
+
+	path := p.Path
+	if path == "" {
+		path = strings.TrimSpace(os.Getenv(ConfigFileEnv))
+	}
+	if path == "" {
+		return nil
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	tree, err := parseConfigFile(filepath.Ext(path), buf)
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	p.values, err = flattenConfig(tree)
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (p *FileProvider) Get(name string) (string, bool, error) {
+	val, ok := p.values[name]
+	return val, ok, nil
+}
+
+func (p *FileProvider) Set(name string, val string) error {
+	if p.values == nil {
+		p.values = map[string]string{}
+	}
+	p.values[name] = val
+	return nil
+}
+
+func parseConfigFile(ext string, buf []byte) (map[string]any, error) {
+	tree := map[string]any{}
+	switch strings.ToLower(ext) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(buf))
+		dec.UseNumber()
+		if err := dec.Decode(&tree); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(buf, &tree); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if _, err := toml.Decode(string(buf), &tree); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file type %q, use .toml, .yaml, .yml or .json", ext)
+	}
+	return tree, nil
+}
+
+// flattenConfig maps nested keys to variable names, e.g. {"database": {"url": ...}}
+// becomes DATABASE_URL.
+func flattenConfig(tree map[string]any) (map[string]string, error) {
+	values := map[string]string{}
+	if err := flattenInto(values, "", tree); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flattenInto(values map[string]string, prefix string, tree map[string]any) error {
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := configVarName(key)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch v := tree[key].(type) {
+		case nil:
+			continue
+		case map[string]any:
+			if err := flattenInto(values, name, v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, exists := values[name]; exists {
+			return fmt.Errorf("%s is set more than once", name)
+		} else if val, err := configValue(tree[key]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		} else {
+			values[name] = val
+		}
+	}
+	return nil
+}
+
+func configVarName(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_", " ", "_").Replace(key))
+}
+
+func configValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		// not fmt.Sprint, which writes 1e6 as "1e+06" that integer vars cannot parse
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return v.String(), nil
+		} else if f, err := v.Float64(); err != nil {
+			return "", err
+		} else {
+			return configValue(f)
+		}
+	case time.Time:
+		return formatConfigTime(v), nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				buf, err := json.Marshal(v)
+				return string(buf), err
+			}
+			if str, err := configValue(item); err != nil {
+				return "", err
+			} else {
+				items = append(items, str)
+			}
+		}
+		return strings.Join(items, ","), nil
+	case []map[string]any: // TOML arrays of tables
+		buf, err := json.Marshal(v)
+		return string(buf), err
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// formatConfigTime writes TOML local dates and times the way they appear in the file, and
+// everything else as RFC3339.
+func formatConfigTime(t time.Time) string {
+	switch t.Location().String() {
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	case "date-local":
+		return t.Format(time.DateOnly)
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
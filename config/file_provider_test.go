@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider(t *testing.T) {
+	expected := map[string]string{
+		"PORT":                   "4000",
+		"DEBUG":                  "true",
+		"ALLOWED_ORIGINS":        "https://example.com,https://admin.example.com",
+		"DATABASE_URL":           "postgres://localhost/app?sslmode=disable",
+		"DATABASE_MAX_IDLE":      "2",
+		"DATABASE_TIMEOUT":       "5s",
+		"SMTP_AUTH_USER":         "mailer",
+		"SMTP_AUTH_LIMITS_DAILY": "1000",
+		"SMTP_AUTH_LIMITS_BURST": "1.5",
+	}
+
+	for _, name := range []string{"config.toml", "config.yaml", "config.json"} {
+		t.Run(name, func(t *testing.T) {
+			p := &FileProvider{Path: filepath.Join("testdata", name)}
+			require.NoError(t, p.Initialize())
+			require.Equal(t, expected, p.values)
+		})
+	}
+}
+
+func TestFileProvider_ConfigFileEnv(t *testing.T) {
+	t.Setenv(ConfigFileEnv, "")
+	p := &FileProvider{}
+	require.NoError(t, p.Initialize())
+	require.Empty(t, p.values)
+
+	t.Setenv(ConfigFileEnv, filepath.Join("testdata", "config.yaml"))
+	require.NoError(t, p.Initialize())
+
+	val, ok, err := p.Get("DATABASE_TIMEOUT")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "5s", val)
+}
+
+func TestFileProvider_EnvOverrides(t *testing.T) {
+	var (
+		urlVar     = Str("TEST_FILE_DATABASE_URL")
+		timeoutVar = Duration("TEST_FILE_DATABASE_TIMEOUT")
+		path       = filepath.Join(t.TempDir(), "config.toml")
+	)
+	require.NoError(t, os.WriteFile(path, []byte(`
+[test_file.database]
+url = "postgres:///from-file"
+timeout = "5s"
+`), 0644))
+
+	t.Setenv("TEST_FILE_DATABASE_URL", "postgres:///from-env")
+	src := NewSource(Chain(EnvProvider{}, &FileProvider{Path: path}), []_Var{urlVar, timeoutVar})
+
+	require.Equal(t, "postgres:///from-env", Get(src, urlVar))
+	require.Equal(t, "env", Origin(src, urlVar))
+	require.Equal(t, 5*time.Second, Get(src, timeoutVar))
+	require.Equal(t, "file", Origin(src, timeoutVar))
+}
+
+func TestFileProvider_Errors(t *testing.T) {
+	cases := map[string]string{
+		"config.ini":     "port = 1",
+		"bad.json":       `{"port": `,
+		"bad.yaml":       "port: [",
+		"dup.toml":       "port = 1\nport = 2",
+		"dup_table.toml": "[db]\nurl = \"a\"\n[db]\nname = \"b\"",
+		"clash.json":     `{"a_b": 1, "a": {"b": 2}}`,
+		"value.toml":     "port = nope",
+		"octal.toml":     "mode = 0755",
+		"leading0.toml":  "port = 08080",
+		"string.toml":    `name = "unterminated`,
+		"trailing.toml":  `name = "a" "b"`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+			require.Error(t, (&FileProvider{Path: path}).Initialize())
+		})
+	}
+
+	require.Error(t, (&FileProvider{Path: filepath.Join(dir, "missing.toml")}).Initialize())
+}
+
+func TestFileProvider_Values(t *testing.T) {
+	cases := map[string]string{
+		"config.toml": `
+hosts = [
+  "a.example.com",
+  "b.example.com", # multi-line arrays are common in deployment configs
+]
+mode = 0o755
+hex = 0x1F
+big = 1e6
+float = 1000000.0
+ratio = 0.25
+when = 1979-05-27T07:32:00Z
+day = 1979-05-27
+local = 1979-05-27T07:32:00
+nested = [[1, 2], ["a"]]
+description = """
+multi-line"""
+
+[[servers]]
+name = "a"
+`,
+		"config.yaml": `
+hosts: [a.example.com, b.example.com]
+mode: 493
+hex: 31
+big: 1e6
+float: 1000000.0
+ratio: 0.25
+when: 1979-05-27T07:32:00Z
+day: "1979-05-27"
+local: "1979-05-27T07:32:00"
+nested: [[1, 2], [a]]
+description: multi-line
+servers: [{name: a}]
+`,
+		"config.json": `{
+  "hosts": ["a.example.com", "b.example.com"],
+  "mode": 493,
+  "hex": 31,
+  "big": 1e6,
+  "float": 1000000.0,
+  "ratio": 0.25,
+  "when": "1979-05-27T07:32:00Z",
+  "day": "1979-05-27",
+  "local": "1979-05-27T07:32:00",
+  "nested": [[1, 2], ["a"]],
+  "description": "multi-line",
+  "servers": [{"name": "a"}]
+}`,
+	}
+
+	expected := map[string]string{
+		"HOSTS":       "a.example.com,b.example.com",
+		"MODE":        "493",
+		"HEX":         "31",
+		"BIG":         "1000000",
+		"FLOAT":       "1000000",
+		"RATIO":       "0.25",
+		"WHEN":        "1979-05-27T07:32:00Z",
+		"DAY":         "1979-05-27",
+		"LOCAL":       "1979-05-27T07:32:00",
+		"NESTED":      `[[1,2],["a"]]`,
+		"DESCRIPTION": "multi-line",
+		"SERVERS":     `[{"name":"a"}]`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+			p := &FileProvider{Path: path}
+			require.NoError(t, p.Initialize())
+			require.Equal(t, expected, p.values)
+		})
+	}
+
+	src := NewSource(&FileProvider{Path: filepath.Join(dir, "config.toml")}, nil)
+	require.Equal(t, 1000000, Get(src, IntDef("BIG", 0)))
+}
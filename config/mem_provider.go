@@ -4,7 +4,12 @@ type MemProvider struct {
 	values map[string]string
 }
 
-var _ Provider = &MemProvider{}
+var (
+	_ NamedProvider    = &MemProvider{}
+	_ ExplicitProvider = &MemProvider{}
+)
+
+func (p *MemProvider) Name() string { return "memory" }
 
 func (p *MemProvider) Initialize() error {
 	p.values = map[string]string{}
@@ -16,6 +21,12 @@ func (p *MemProvider) Get(name string) (string, bool, error) {
 	return val, ok, nil
 }
 
+// Explicit reports whether name was set, every value in memory was set on purpose.
+func (p *MemProvider) Explicit(name string) bool {
+	_, ok := p.values[name]
+	return ok
+}
+
 func (p *MemProvider) Set(name string, val string) error {
 	p.values[name] = val
 	return nil
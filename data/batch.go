@@ -0,0 +1,133 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Postgres' wire protocol limits a single statement to this many bind parameters.
+const maxBindParams = 65535
+
+var (
+	// MaxBatchRows bounds how many rows InsertBatch and UpsertBatch put in a single INSERT
+	// statement. Batches are further split so they never exceed the bind parameter limit.
+	MaxBatchRows = 500
+
+	ErrNoConflictColumns = errors.New("data: upsert requires at least one conflict column")
+	ErrNilRow            = errors.New("data: cannot write a nil row")
+)
+
+// InsertBatch inserts rows into table with multi-row INSERT statements. Columns are taken
+// from the `db` tags of T, fields tagged with the `default` option (e.g. `db:"id,default"`)
+// are written as DEFAULT when zero.
+//
+// All statements run in the ambient Scope, or a new one, so the batch either fully
+// succeeds or fails.
+func InsertBatch[T any](ctx context.Context, table string, rows []T) error {
+	return writeBatch(ctx, table, rows, nil)
+}
+
+// UpsertBatch is InsertBatch with an `ON CONFLICT (conflict...) DO UPDATE` clause which
+// overwrites every other column, except the ones tagged with the `default` option, with
+// the new values.
+func UpsertBatch[T any](ctx context.Context, table string, conflict []string, rows []T) error {
+	if len(conflict) == 0 {
+		return ErrNoConflictColumns
+	}
+	return writeBatch(ctx, table, rows, conflict)
+}
+
+func writeBatch[T any](ctx context.Context, table string, rows []T, conflict []string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns, err := columnsOf(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+
+	size := min(MaxBatchRows, maxBindParams/len(columns))
+	if size < 1 {
+		size = 1
+	}
+
+	return Run(ctx, func(s Scope) error {
+		for start := 0; start < len(rows); start += size {
+			end := min(start+size, len(rows))
+			if sql, args, err := batchSQL(table, columns, rows[start:end], conflict); err != nil {
+				return err
+			} else if err := s.Exec(sql, args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func batchSQL[T any](table string, columns []column, rows []T, conflict []string) (string, []any, error) {
+	var (
+		sb    strings.Builder
+		args  []any
+		names = make([]string, len(columns))
+	)
+	for idx, col := range columns {
+		names[idx] = col.quoted()
+	}
+
+	sb.WriteString("INSERT INTO " + quoteIdent(table))
+	sb.WriteString(" (" + strings.Join(names, ", ") + ") VALUES ")
+
+	for rowIdx, row := range rows {
+		rv := reflect.ValueOf(row)
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return "", nil, ErrNilRow
+		}
+
+		if rowIdx > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for colIdx, col := range columns {
+			if colIdx > 0 {
+				sb.WriteString(", ")
+			}
+			if value, isDefault := col.value(rv); isDefault {
+				sb.WriteString("DEFAULT")
+			} else {
+				args = append(args, value)
+				sb.WriteString("$" + strconv.Itoa(len(args)))
+			}
+		}
+		sb.WriteString(")")
+	}
+
+	if len(conflict) > 0 {
+		var (
+			targets []string
+			updates []string
+		)
+		for _, name := range conflict {
+			targets = append(targets, quoteIdent(name))
+		}
+		for idx, col := range columns {
+			if col.hasDefault || slices.Contains(conflict, col.name) {
+				continue
+			}
+			updates = append(updates, names[idx]+" = EXCLUDED."+names[idx])
+		}
+
+		sb.WriteString(" ON CONFLICT (" + strings.Join(targets, ", ") + ")")
+		if len(updates) == 0 {
+			sb.WriteString(" DO NOTHING")
+		} else {
+			sb.WriteString(" DO UPDATE SET " + strings.Join(updates, ", "))
+		}
+	}
+
+	return sb.String(), args, nil
+}
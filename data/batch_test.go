@@ -0,0 +1,65 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type batchBase struct {
+	ID        int64     `db:"id,default"`
+	CreatedAt time.Time `db:"created_at,default"`
+}
+
+type batchRow struct {
+	batchBase
+	Email  string `db:"email"`
+	Name   string `db:"name"`
+	Secret string `db:"-"`
+}
+
+func TestBatchSQL(t *testing.T) {
+	columns, err := columnsOf(reflect.TypeFor[batchRow]())
+	require.NoError(t, err)
+
+	rows := []*batchRow{
+		{Email: "a@example.com", Name: "A"},
+		{batchBase: batchBase{ID: 7}, Email: "b@example.com", Name: "B"},
+	}
+
+	sql, args, err := batchSQL("public.users", columns, rows, nil)
+	require.NoError(t, err)
+	require.Equal(t, `INSERT INTO "public"."users" ("id", "created_at", "email", "name") VALUES `+
+		`(DEFAULT, DEFAULT, $1, $2), ($3, DEFAULT, $4, $5)`, sql)
+	require.Equal(t, []any{"a@example.com", "A", int64(7), "b@example.com", "B"}, args)
+
+	sql, _, err = batchSQL("users", columns, rows[:1], []string{"email"})
+	require.NoError(t, err)
+	require.Equal(t, `INSERT INTO "users" ("id", "created_at", "email", "name") VALUES `+
+		`(DEFAULT, DEFAULT, $1, $2) ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name"`, sql)
+
+	sql, _, err = batchSQL("users", columns, rows[:1], []string{"email", "name"})
+	require.NoError(t, err)
+	require.Contains(t, sql, `ON CONFLICT ("email", "name") DO NOTHING`)
+
+	_, _, err = batchSQL("users", columns, []*batchRow{nil}, nil)
+	require.ErrorIs(t, err, ErrNilRow)
+}
+
+func TestColumnsOf(t *testing.T) {
+	_, err := columnsOf(reflect.TypeFor[int]())
+	require.Error(t, err)
+
+	columns, err := columnsOf(reflect.TypeFor[*batchRow]())
+	require.NoError(t, err)
+
+	var names []string
+	for _, col := range columns {
+		names = append(names, col.name)
+	}
+	require.Equal(t, []string{"id", "created_at", "email", "name"}, names)
+	require.True(t, columns[0].hasDefault)
+	require.False(t, columns[2].hasDefault)
+}
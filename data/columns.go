@@ -0,0 +1,79 @@
+package data
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// DefaultTagOption marks a column that has a database-side default, e.g.
+// `db:"id,default"` for a serial primary key. Zero values in such fields are written as
+// DEFAULT instead of the Go zero value and the columns are left alone on upserts.
+const DefaultTagOption = "default"
+
+var mapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+type column struct {
+	name       string
+	index      []int
+	hasDefault bool
+	isPK       bool
+}
+
+// columnsOf lists the top-level columns of a struct type the same way sqlx maps them,
+// fields from embedded structs are flattened.
+func columnsOf(typ reflect.Type) ([]column, error) {
+	typ = reflectx.Deref(typ)
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("data: %s is not a struct", typ)
+	}
+
+	var columns []column
+	for _, info := range mapper.TypeMap(typ).Index {
+		if info.Embedded || info.Name == "" || strings.Contains(info.Path, ".") {
+			continue
+		}
+
+		_, hasDefault := info.Options[DefaultTagOption]
+		_, isPK := info.Options[PrimaryKeyTagOption]
+		columns = append(columns, column{
+			name:       info.Name,
+			index:      info.Index,
+			hasDefault: hasDefault,
+			isPK:       isPK,
+		})
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("data: %s has no columns", typ)
+	}
+
+	// the mapper walks embedded structs breadth-first, put them back in declaration order
+	slices.SortStableFunc(columns, func(a, b column) int { return slices.Compare(a.index, b.index) })
+	return columns, nil
+}
+
+func (c column) quoted() string { return quoteIdent(c.name) }
+
+func (c column) field(row reflect.Value) reflect.Value {
+	return reflectx.FieldByIndexesReadOnly(reflect.Indirect(row), c.index)
+}
+
+// value returns the column's value in row and whether it should be written as DEFAULT.
+func (c column) value(row reflect.Value) (any, bool) {
+	field := c.field(row)
+	if c.hasDefault && field.IsZero() {
+		return nil, true
+	}
+	return field.Interface(), false
+}
+
+// quoteIdent quotes a possibly schema-qualified identifier such as `public.todos`.
+func quoteIdent(name string) string {
+	return pgx.Identifier(strings.Split(name, ".")).Sanitize()
+}
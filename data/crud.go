@@ -0,0 +1,142 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PrimaryKeyTagOption marks the primary key column for the CRUD helpers, e.g.
+// `db:"key,pk"`. Without it, the column named `id` is used.
+const PrimaryKeyTagOption = "pk"
+
+var ErrNoPrimaryKey = errors.New("data: no primary key column, tag one with the `pk` option or name it `id`")
+
+// Table is implemented by structs used with the CRUD helpers to name their table.
+type Table interface {
+	TableName() string
+}
+
+type tablePtr[T any] interface {
+	*T
+	Table
+}
+
+// FindByID loads a single row by primary key. Like Get, it returns an error matching
+// IsNoRows if there is no such row.
+//
+//	todo, err := data.FindByID[Todo](ctx, id)
+func FindByID[T any, PT tablePtr[T]](ctx context.Context, id any) (*T, error) {
+	table, pk, _, err := crudInfo[T, PT]()
+	if err != nil {
+		return nil, err
+	}
+
+	row := new(T)
+	sql := "SELECT * FROM " + quoteIdent(table) + " WHERE " + pk.quoted() + " = $1"
+	if err := Get(ctx, row, sql, id); err != nil {
+		return nil, err
+	} else {
+		return row, nil
+	}
+}
+
+// Insert inserts row and scans the inserted row back into it, picking up database-side
+// defaults. Zero-valued fields tagged with the `default` option are written as DEFAULT.
+func Insert[T any, PT tablePtr[T]](ctx context.Context, row *T) error {
+	table, _, columns, err := crudInfo[T, PT]()
+	if err != nil {
+		return err
+	}
+
+	sql, args, err := batchSQL(table, columns, []*T{row}, nil)
+	if err != nil {
+		return err
+	}
+	return Get(ctx, row, sql+" RETURNING *", args...)
+}
+
+// Update writes all columns of row to the row with the same primary key and scans the
+// result back into it. Zero-valued fields tagged with the `default` option are left as
+// they are in the database.
+func Update[T any, PT tablePtr[T]](ctx context.Context, row *T) error {
+	table, pk, columns, err := crudInfo[T, PT]()
+	if err != nil {
+		return err
+	} else if row == nil {
+		return ErrNilRow
+	}
+
+	sql, args, err := updateSQL(table, pk, columns, reflect.ValueOf(row))
+	if err != nil {
+		return err
+	}
+	return Get(ctx, row, sql, args...)
+}
+
+// Delete deletes a single row by primary key and returns the deleted row.
+func Delete[T any, PT tablePtr[T]](ctx context.Context, id any) (*T, error) {
+	table, pk, _, err := crudInfo[T, PT]()
+	if err != nil {
+		return nil, err
+	}
+
+	row := new(T)
+	sql := "DELETE FROM " + quoteIdent(table) + " WHERE " + pk.quoted() + " = $1 RETURNING *"
+	if err := Get(ctx, row, sql, id); err != nil {
+		return nil, err
+	} else {
+		return row, nil
+	}
+}
+
+func crudInfo[T any, PT tablePtr[T]]() (table string, pk column, columns []column, err error) {
+	if columns, err = columnsOf(reflect.TypeFor[T]()); err != nil {
+		return
+	}
+
+	found := false
+	for _, col := range columns {
+		if col.isPK {
+			pk, found = col, true
+			break
+		} else if col.name == "id" {
+			pk, found = col, true
+		}
+	}
+	if !found {
+		err = ErrNoPrimaryKey
+		return
+	}
+
+	table = PT(new(T)).TableName()
+	return
+}
+
+func updateSQL(table string, pk column, columns []column, row reflect.Value) (string, []any, error) {
+	var (
+		sets []string
+		args []any
+	)
+	for _, col := range columns {
+		if col.name == pk.name {
+			continue
+		} else if value, isDefault := col.value(row); !isDefault {
+			args = append(args, value)
+			sets = append(sets, col.quoted()+" = $"+strconv.Itoa(len(args)))
+		}
+	}
+	if len(sets) == 0 {
+		return "", nil, fmt.Errorf("data: %s has no columns to update", table)
+	}
+
+	args = append(args, pk.field(row).Interface())
+	sql := "UPDATE " + quoteIdent(table) +
+		" SET " + strings.Join(sets, ", ") +
+		" WHERE " + pk.quoted() + " = $" + strconv.Itoa(len(args)) +
+		" RETURNING *"
+	return sql, args, nil
+}
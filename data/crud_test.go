@@ -0,0 +1,66 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type crudTodo struct {
+	ID        int64     `db:"id,default"`
+	Title     string    `db:"title"`
+	CreatedAt time.Time `db:"created_at,default"`
+}
+
+func (crudTodo) TableName() string { return "todos" }
+
+type crudSetting struct {
+	Key   string `db:"key,pk"`
+	Value string `db:"value"`
+}
+
+func (*crudSetting) TableName() string { return "app.settings" }
+
+type crudNoPK struct {
+	Name string `db:"name"`
+}
+
+func (crudNoPK) TableName() string { return "nopk" }
+
+func TestCrudInfo(t *testing.T) {
+	table, pk, columns, err := crudInfo[crudTodo]()
+	require.NoError(t, err)
+	require.Equal(t, "todos", table)
+	require.Equal(t, "id", pk.name)
+	require.Len(t, columns, 3)
+
+	table, pk, _, err = crudInfo[crudSetting]()
+	require.NoError(t, err)
+	require.Equal(t, "app.settings", table)
+	require.Equal(t, "key", pk.name)
+
+	_, _, _, err = crudInfo[crudNoPK]()
+	require.ErrorIs(t, err, ErrNoPrimaryKey)
+}
+
+func TestUpdateSQL(t *testing.T) {
+	_, pk, columns, err := crudInfo[crudTodo]()
+	require.NoError(t, err)
+
+	row := &crudTodo{ID: 3, Title: "milk"}
+	sql, args, err := updateSQL("todos", pk, columns, reflect.ValueOf(row))
+	require.NoError(t, err)
+	require.Equal(t, `UPDATE "todos" SET "title" = $1 WHERE "id" = $2 RETURNING *`, sql)
+	require.Equal(t, []any{"milk", int64(3)}, args)
+
+	_, pk, columns, err = crudInfo[crudSetting]()
+	require.NoError(t, err)
+
+	setting := &crudSetting{Key: "theme", Value: "dark"}
+	sql, args, err = updateSQL("app.settings", pk, columns, reflect.ValueOf(setting))
+	require.NoError(t, err)
+	require.Equal(t, `UPDATE "app"."settings" SET "value" = $1 WHERE "key" = $2 RETURNING *`, sql)
+	require.Equal(t, []any{"dark", "theme"}, args)
+}
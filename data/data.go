@@ -19,6 +19,21 @@ var (
 	DatabaseURLConfig     = config.Str("DATABASE_URL")
 	DatabaseMaxIdleConfig = config.IntDef("DATABASE_MAX_IDLE", runtime.NumCPU())
 	DatabaseMaxOpenConfig = config.IntDef("DATABASE_MAX_OPEN", -1)
+
+	// DatabaseConnMaxLifetimeConfig and DatabaseConnMaxIdleTimeConfig close pooled
+	// connections once they are this old or have been idle this long. Unlimited when
+	// empty or zero.
+	DatabaseConnMaxLifetimeConfig = config.Duration("DATABASE_CONN_MAX_LIFETIME")
+	DatabaseConnMaxIdleTimeConfig = config.Duration("DATABASE_CONN_MAX_IDLE_TIME")
+
+	// DatabasePoolStatsConfig samples the connection pool statistics at the given
+	// interval, logging them through fxlog, passing them to PoolHooks and warning when
+	// queries had to wait for a connection. Disabled when empty or zero.
+	DatabasePoolStatsConfig = config.Duration("DATABASE_POOL_STATS")
+
+	// DatabaseSlowQueryConfig logs every statement that takes longer than the given
+	// duration through fxlog. Disabled when empty or zero.
+	DatabaseSlowQueryConfig = config.Duration("DATABASE_SLOW_QUERY")
 )
 
 func MustConnect(cfg *config.Source) *sqlx.DB {
@@ -49,6 +64,22 @@ func configureDB(cfg *config.Source, db *sqlx.DB) {
 	if maxOpen > 0 {
 		db.SetMaxOpenConns(maxOpen)
 	}
+
+	maxLifetime, maxIdleTime :=
+		config.Get(cfg, DatabaseConnMaxLifetimeConfig),
+		config.Get(cfg, DatabaseConnMaxIdleTimeConfig)
+	if maxLifetime > 0 {
+		db.SetConnMaxLifetime(maxLifetime)
+	}
+	if maxIdleTime > 0 {
+		db.SetConnMaxIdleTime(maxIdleTime)
+	}
+
+	if interval := config.Get(cfg, DatabasePoolStatsConfig); interval > 0 {
+		go watchPool(db.DB, interval)
+	}
+
+	slowQueryThreshold.Store(int64(config.Get(cfg, DatabaseSlowQueryConfig)))
 }
 
 func CreateDB(cfg *config.Source) error {
@@ -102,7 +133,31 @@ func NewScope(ctx context.Context, db *sqlx.DB) (Scope, error) {
 		db = FromContext(ctx)
 	}
 
-	if impl, err := newScope(ctx, db); err != nil {
+	if impl, err := newScope(ctx, db, nil, false); err != nil {
+		return nil, err
+	} else {
+		return impl, nil
+	}
+}
+
+// NewRollbackScope is NewScope for a transaction that is rolled back when the scope ends
+// even if there was no error. Everything run with its Context(), including Run and
+// RunWith, joins the transaction as a child scope and is discarded at the end. This lets
+// tests share a database without seeing each other's data, see
+// fxtest.ConnectTxDatabase. AfterCommit hooks never run in such a transaction,
+// AfterRollback hooks run when it ends.
+//
+// Each direct child scope, which would be a transaction of its own outside of the rollback
+// scope, runs inside a SAVEPOINT that is rolled back if the child ends with an error, so
+// an expected failure such as a unique violation undoes only that child's work and later
+// statements keep working. Scopes nested deeper share their parent's savepoint, so a
+// failed statement aborts them as it would in production.
+func NewRollbackScope(ctx context.Context, db *sqlx.DB) (Scope, error) {
+	if db == nil {
+		db = FromContext(ctx)
+	}
+
+	if impl, err := newScope(ctx, db, nil, true); err != nil {
 		return nil, err
 	} else {
 		return impl, nil
@@ -0,0 +1,153 @@
+package data
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fx.prodigy9.co/fxlog"
+)
+
+type (
+	// QueryEvent describes a single statement executed through a Scope.
+	QueryEvent struct {
+		SQL      string
+		Args     int
+		Duration time.Duration
+		Rows     int64
+		Err      error
+	}
+
+	// QueryHook is invoked around every statement executed through a Scope's Get, Select
+	// and Exec methods. The context returned from BeforeQuery is passed to the next hook
+	// and finally used to execute the statement. Each hook's AfterQuery receives the
+	// context its own BeforeQuery returned, so hooks can carry state (e.g. spans) between
+	// the two calls even when several hooks store it under the same key.
+	//
+	// Statements run through Prepare are not instrumented since their execution happens
+	// on the returned *sqlx.Stmt.
+	QueryHook interface {
+		BeforeQuery(ctx context.Context, sql string, args int) context.Context
+		AfterQuery(ctx context.Context, event QueryEvent)
+	}
+
+	// Span is the minimal subset of a tracing span (e.g. OpenTelemetry's trace.Span) that
+	// TraceQueries needs. StartSpanFunc starts one, usually by wrapping a tracer's Start.
+	Span interface {
+		SetAttribute(key string, value any)
+		RecordError(err error)
+		End()
+	}
+
+	StartSpanFunc func(ctx context.Context, name string) (context.Context, Span)
+
+	traceHook struct {
+		start StartSpanFunc
+	}
+
+	spanKey struct{}
+)
+
+var (
+	hooksMutex sync.RWMutex
+	hooks      []QueryHook
+
+	slowQueryThreshold atomic.Int64
+)
+
+// AddQueryHook registers a QueryHook for all statements executed through the data
+// package. Hooks accumulate and are called in the order they are added.
+func AddQueryHook(hook QueryHook) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+	hooks = append(hooks, hook)
+}
+
+func queryHooks() []QueryHook {
+	hooksMutex.RLock()
+	defer hooksMutex.RUnlock()
+	return hooks
+}
+
+// TraceQueries returns a QueryHook that wraps each statement in a span started with the
+// given function.
+func TraceQueries(start StartSpanFunc) QueryHook {
+	return traceHook{start}
+}
+
+func (h traceHook) BeforeQuery(ctx context.Context, sql string, args int) context.Context {
+	ctx, span := h.start(ctx, "data.query")
+	span.SetAttribute("db.system", "postgresql")
+	span.SetAttribute("db.statement", sql)
+	span.SetAttribute("db.args", args)
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+func (h traceHook) AfterQuery(ctx context.Context, event QueryEvent) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttribute("db.rows", event.Rows)
+	if event.Err != nil {
+		span.RecordError(event.Err)
+	}
+	span.End()
+}
+
+func instrument(ctx context.Context, sql string, args int, run func(context.Context) (int64, error)) error {
+	var (
+		hooks     = queryHooks()
+		threshold = time.Duration(slowQueryThreshold.Load())
+	)
+	if len(hooks) == 0 && threshold <= 0 {
+		_, err := run(ctx)
+		return err
+	}
+
+	hookCtxs := make([]context.Context, len(hooks))
+	for idx, hook := range hooks {
+		ctx = hook.BeforeQuery(ctx, sql, args)
+		hookCtxs[idx] = ctx
+	}
+
+	start := time.Now()
+	rows, err := run(ctx)
+	event := QueryEvent{
+		SQL:      sql,
+		Args:     args,
+		Duration: time.Since(start),
+		Rows:     rows,
+		Err:      err,
+	}
+
+	for idx := len(hooks) - 1; idx >= 0; idx-- {
+		hooks[idx].AfterQuery(hookCtxs[idx], event)
+	}
+	if threshold > 0 && event.Duration >= threshold {
+		fxlog.Log("slow query",
+			fxlog.String("sql", strings.Join(strings.Fields(sql), " ")),
+			fxlog.Int("args", event.Args),
+			fxlog.Duration("duration", event.Duration),
+			fxlog.Int64("rows", event.Rows),
+		)
+	}
+	return err
+}
+
+// countRows returns the number of rows scanned into dest by Select
+func countRows(dest any) int64 {
+	val := reflect.ValueOf(dest)
+	for val.Kind() == reflect.Pointer && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Slice {
+		return int64(val.Len())
+	} else {
+		return 0
+	}
+}
@@ -0,0 +1,103 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHook struct {
+	name   string
+	calls  *[]string
+	events []QueryEvent
+}
+
+type recordingKey struct{}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, sql string, args int) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+	return context.WithValue(ctx, recordingKey{}, h.name)
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, event QueryEvent) {
+	*h.calls = append(*h.calls, "after "+h.name+" in "+ctx.Value(recordingKey{}).(string))
+	h.events = append(h.events, event)
+}
+
+func TestInstrument(t *testing.T) {
+	saved := hooks
+	t.Cleanup(func() { hooks = saved })
+	hooks = nil
+
+	var calls []string
+	first := &recordingHook{name: "first", calls: &calls}
+	second := &recordingHook{name: "second", calls: &calls}
+	AddQueryHook(first)
+	AddQueryHook(second)
+
+	failure := errors.New("failure")
+	err := instrument(context.Background(), "SELECT $1", 1, func(ctx context.Context) (int64, error) {
+		require.Equal(t, "second", ctx.Value(recordingKey{}))
+		return 3, failure
+	})
+	require.ErrorIs(t, err, failure)
+
+	require.Equal(t, []string{
+		"before first",
+		"before second",
+		"after second in second",
+		"after first in first",
+	}, calls)
+	require.Len(t, first.events, 1)
+	require.Equal(t, "SELECT $1", first.events[0].SQL)
+	require.Equal(t, 1, first.events[0].Args)
+	require.Equal(t, int64(3), first.events[0].Rows)
+	require.ErrorIs(t, first.events[0].Err, failure)
+}
+
+type recordingSpan struct {
+	name  string
+	attrs map[string]any
+	ended int
+}
+
+func (s *recordingSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *recordingSpan) RecordError(err error)              {}
+func (s *recordingSpan) End()                               { s.ended++ }
+
+func TestTraceQueries_Multiple(t *testing.T) {
+	saved := hooks
+	t.Cleanup(func() { hooks = saved })
+	hooks = nil
+
+	var spans []*recordingSpan
+	tracer := func(name string) StartSpanFunc {
+		return func(ctx context.Context, _ string) (context.Context, Span) {
+			span := &recordingSpan{name: name, attrs: map[string]any{}}
+			spans = append(spans, span)
+			return ctx, span
+		}
+	}
+	AddQueryHook(TraceQueries(tracer("a")))
+	AddQueryHook(TraceQueries(tracer("b")))
+
+	err := instrument(context.Background(), "SELECT 1", 0, func(context.Context) (int64, error) {
+		return 1, nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, spans, 2)
+	for _, span := range spans {
+		require.Equal(t, 1, span.ended, "span %s", span.name)
+		require.Equal(t, int64(1), span.attrs["db.rows"])
+	}
+}
+
+func TestCountRows(t *testing.T) {
+	rows := []int{1, 2, 3}
+	require.Equal(t, int64(3), countRows(&rows))
+	require.Equal(t, int64(0), countRows(new(int)))
+	require.Equal(t, int64(0), countRows(nil))
+}
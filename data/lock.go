@@ -0,0 +1,159 @@
+package data
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+const pgLockNotAvailable = "55P03"
+
+var ErrLockTimeout = errors.New("data: timed out waiting for advisory lock")
+
+// LockOptions controls how WithAdvisoryLock and TryAdvisoryLock take their lock.
+type LockOptions struct {
+	// Timeout bounds how long WithAdvisoryLock waits for the lock before failing with
+	// ErrLockTimeout. Zero waits until ctx is done. TryAdvisoryLock never waits.
+	Timeout time.Duration
+
+	// Session takes a session-level lock on a dedicated connection instead of a
+	// transaction-level one. The lock is then held across however many transactions the
+	// action runs, which is what long-running singleton tasks usually want.
+	Session bool
+}
+
+// AdvisoryLockID returns the 64-bit key used for the named lock. In pg_locks it shows up
+// split into classid (high 32 bits) and objid (low 32 bits) with objsubid = 1.
+func AdvisoryLockID(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return int64(h.Sum64())
+}
+
+// WithAdvisoryLock runs action while holding a cluster-wide Postgres advisory lock named
+// by key, waiting for it if another process holds it.
+//
+// By default, the lock is taken with pg_advisory_xact_lock inside the ambient scope (or a
+// new one) and released when that transaction ends, so the context given to action
+// carries the transaction. Note that a lock timeout aborts that transaction. With
+// opts.Session, the lock is held on a dedicated connection and released as soon as action
+// returns.
+func WithAdvisoryLock(ctx context.Context, key string, opts LockOptions, action func(ctx context.Context) error) error {
+	_, err := advisoryLock(ctx, key, opts, true, action)
+	return err
+}
+
+// TryAdvisoryLock is like WithAdvisoryLock but does not wait. If the lock is held
+// elsewhere it returns false without running action.
+func TryAdvisoryLock(ctx context.Context, key string, opts LockOptions, action func(ctx context.Context) error) (bool, error) {
+	return advisoryLock(ctx, key, opts, false, action)
+}
+
+func advisoryLock(ctx context.Context, key string, opts LockOptions, wait bool, action func(context.Context) error) (locked bool, err error) {
+	id := AdvisoryLockID(key)
+	if opts.Session {
+		return sessionLock(ctx, id, opts.Timeout, wait, action)
+	}
+
+	err = Run(ctx, func(s Scope) error {
+		if wait {
+			if err := withLockTimeout(s, opts.Timeout, func() error {
+				return s.Exec("SELECT pg_advisory_xact_lock($1)", id)
+			}); err != nil {
+				return lockError(err)
+			}
+			locked = true
+		} else if err := s.Get(&locked, "SELECT pg_try_advisory_xact_lock($1)", id); err != nil {
+			return err
+		} else if !locked {
+			return nil
+		}
+
+		return action(s.Context())
+	})
+	return locked, err
+}
+
+// withLockTimeout sets the transaction's lock_timeout for the duration of fn, restoring
+// the previous value afterwards so an outer transaction's setting is left intact.
+func withLockTimeout(s Scope, timeout time.Duration, fn func() error) error {
+	if timeout <= 0 {
+		return fn()
+	}
+
+	var prev string
+	if err := s.Get(&prev, "SELECT current_setting('lock_timeout')"); err != nil {
+		return err
+	} else if err := s.Exec("SELECT set_config('lock_timeout', $1, true)", lockTimeoutValue(timeout)); err != nil {
+		return err
+	} else if err := fn(); err != nil {
+		return err
+	} else {
+		return s.Exec("SELECT set_config('lock_timeout', $1, true)", prev)
+	}
+}
+
+func sessionLock(ctx context.Context, id int64, timeout time.Duration, wait bool, action func(context.Context) error) (locked bool, err error) {
+	db := FromContext(ctx)
+
+	var conn *sqlx.Conn
+	if conn, err = db.Connx(ctx); err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if !wait {
+		if err = conn.GetContext(ctx, &locked, "SELECT pg_try_advisory_lock($1)", id); err != nil || !locked {
+			return false, err
+		}
+	} else {
+		if timeout > 0 {
+			if _, err = conn.ExecContext(ctx, "SET lock_timeout = '"+lockTimeoutValue(timeout)+"'"); err != nil {
+				return false, err
+			}
+		}
+
+		_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", id)
+		if timeout > 0 {
+			if _, resetErr := conn.ExecContext(context.WithoutCancel(ctx), "RESET lock_timeout"); resetErr != nil && err == nil {
+				err = resetErr
+			}
+		}
+		if err != nil {
+			return false, lockError(err)
+		}
+		locked = true
+	}
+
+	defer func() {
+		_, unlockErr := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", id)
+		if unlockErr != nil {
+			// don't return a connection still holding the lock to the pool
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			if err == nil {
+				err = fmt.Errorf("data: advisory unlock: %w", unlockErr)
+			}
+		}
+	}()
+
+	return true, action(ctx)
+}
+
+func lockTimeoutValue(timeout time.Duration) string {
+	return strconv.FormatInt(max(timeout.Milliseconds(), 1), 10) + "ms"
+}
+
+func lockError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgLockNotAvailable {
+		return fmt.Errorf("%w: %w", ErrLockTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryLockID(t *testing.T) {
+	require.Equal(t, AdvisoryLockID("nightly-report"), AdvisoryLockID("nightly-report"))
+	require.NotEqual(t, AdvisoryLockID("nightly-report"), AdvisoryLockID("migrations"))
+}
+
+func TestLockTimeoutValue(t *testing.T) {
+	require.Equal(t, "1500ms", lockTimeoutValue(1500*time.Millisecond))
+	require.Equal(t, "1ms", lockTimeoutValue(time.Microsecond))
+}
+
+func TestLockError(t *testing.T) {
+	timeout := lockError(&pgconn.PgError{Code: "55P03"})
+	require.ErrorIs(t, timeout, ErrLockTimeout)
+
+	other := errors.New("other")
+	require.Equal(t, other, lockError(other))
+}
@@ -1,6 +1,10 @@
 package data
 
-import "context"
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
 
 // small shim to support sql generators like go-jet
 type SQLGenerator interface {
@@ -16,6 +20,13 @@ func Select(ctx context.Context, out any, sql string, args ...any) (err error) {
 func Exec(ctx context.Context, sql string, args ...any) error {
 	return Run(ctx, func(s Scope) error { return s.Exec(sql, args...) })
 }
+func CopyFrom(ctx context.Context, table string, columns []string, rows pgx.CopyFromSource) (n int64, err error) {
+	err = Run(ctx, func(s Scope) (err error) {
+		n, err = s.CopyFrom(table, columns, rows)
+		return
+	})
+	return
+}
 
 func GetSQL(ctx context.Context, out any, sqlgen SQLGenerator) (err error) {
 	sql, args := sqlgen.Sql()
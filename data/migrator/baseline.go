@@ -0,0 +1,244 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+const (
+	// BaselineMarker, in the leading comments of a migration file, marks a baseline
+	// created by `data squash-migrations`: a schema dump that replaces all migrations
+	// before it. See LoadAuto.
+	BaselineMarker = "-- fx:baseline"
+
+	// SupersedesMarker lists, one per line, the migrations a baseline replaces.
+	SupersedesMarker = "-- fx:supersedes "
+
+	BaselineName    = "baseline"
+	baselineDownSQL = `DO $$ BEGIN RAISE EXCEPTION 'baseline migrations cannot be rolled back'; END $$;`
+)
+
+// IsBaseline reports whether m is a baseline migration, see BaselineMarker.
+func (m Migration) IsBaseline() bool {
+	return hasMarker(m.UpSQL, BaselineMarker)
+}
+
+// Supersedes lists the migrations a baseline migration replaces.
+func (m Migration) Supersedes() (names []string) {
+	for _, line := range headerLines(m.UpSQL) {
+		if name, ok := strings.CutPrefix(line, SupersedesMarker); ok {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	return names
+}
+
+// NewBaseline creates a baseline migration from a schema dump that replaces the given
+// migrations.
+func NewBaseline(name, schema string, supersedes []string) Migration {
+	sb := &strings.Builder{}
+	sb.WriteString(BaselineMarker + "\n")
+	for _, superseded := range supersedes {
+		sb.WriteString(SupersedesMarker + superseded + "\n")
+	}
+	sb.WriteString("\n" + strings.TrimSpace(schema) + "\n")
+
+	return Migration{
+		Name:    name,
+		UpSQL:   sb.String(),
+		DownSQL: baselineDownSQL + "\n",
+	}
+}
+
+// DumpSchema dumps the schema of the database at dbURL with pg_dump, without the
+// migrations table, ownership or privileges. The session-level settings pg_dump emits are
+// removed since migrations run on pooled connections.
+func DumpSchema(ctx context.Context, dbURL string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	proc := exec.CommandContext(ctx, "pg_dump",
+		"--schema-only",
+		"--no-owner",
+		"--no-privileges",
+		"--exclude-table=public.migrations",
+		dbURL,
+	)
+	proc.Stdout, proc.Stderr = stdout, stderr
+	if err := proc.Run(); err != nil {
+		return "", fmt.Errorf("migrator: pg_dump: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return cleanDump(stdout.String()), nil
+}
+
+// cleanDump drops the session settings and psql meta-commands pg_dump writes around the
+// schema. Only lines at the top level are touched, those inside function bodies and
+// other quoted text are kept exactly as dumped.
+func cleanDump(dump string) string {
+	var (
+		lines   []string
+		blank   = true
+		quoteTo string
+	)
+	for _, line := range strings.Split(dump, "\n") {
+		topLevel := quoteTo == ""
+		quoteTo = dumpQuoteEnd(line, quoteTo)
+
+		switch {
+		case !topLevel:
+			blank = false
+		case strings.HasPrefix(line, "SET "),
+			strings.HasPrefix(line, "SELECT pg_catalog.set_config("),
+			strings.HasPrefix(line, `\`): // psql meta-commands, e.g. \restrict
+			continue
+		case strings.TrimSpace(line) == "":
+			// collapse runs of blank lines left behind
+			if blank {
+				continue
+			}
+			blank = true
+		default:
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+}
+
+// dumpQuoteEnd scans line of a dump that starts inside quoted text closed by quoteTo, or
+// at the top level if quoteTo is empty, and returns what closes the quoted text the line
+// ends in, if any. Like splitStatements, it knows about strings, quoted identifiers,
+// dollar-quoted bodies and comments.
+func dumpQuoteEnd(line, quoteTo string) string {
+	for idx := 0; idx < len(line); {
+		rest := line[idx:]
+		if quoteTo != "" {
+			pos := strings.Index(rest, quoteTo)
+			if pos < 0 {
+				return quoteTo
+			}
+			idx, quoteTo = idx+pos+len(quoteTo), ""
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			return ""
+		case strings.HasPrefix(rest, "/*"):
+			idx, quoteTo = idx+2, "*/"
+		case rest[0] == '\'' || rest[0] == '"':
+			idx, quoteTo = idx+1, rest[:1]
+		case rest[0] == '$':
+			if tag := dollarTag(rest); tag != "" {
+				idx, quoteTo = idx+len(tag), tag
+			} else {
+				idx++
+			}
+		default:
+			idx++
+		}
+	}
+	return quoteTo
+}
+
+// fromBaseline drops the migrations that baselines replace. Only the ones a baseline
+// lists are dropped rather than everything older than it, since a baseline covers all
+// namespaces and a fragment may later ship a migration with an older timestamp, e.g.
+// after a library upgrade, that still needs to be applied.
+func fromBaseline(migrations []Migration) []Migration {
+	superseded := pendingSupersedes(migrations)
+	return slices.DeleteFunc(slices.Clone(migrations), func(m Migration) bool {
+		return superseded[m.Name]
+	})
+}
+
+// activeMigrations drops rows superseded by a baseline, which are only kept for history.
+func activeMigrations(inDB []Migration) []Migration {
+	return slices.DeleteFunc(slices.Clone(inDB), func(m Migration) bool { return m.SupersededBy != "" })
+}
+
+// pendingSupersedes lists migrations that baselines in the source will supersede once
+// recorded. Their absence from the source is expected.
+func pendingSupersedes(inFiles []Migration) map[string]bool {
+	result := map[string]bool{}
+	for _, mig := range inFiles {
+		for _, name := range mig.Supersedes() {
+			result[name] = true
+		}
+	}
+	return result
+}
+
+// baselineAction decides how a pending baseline is handled: run on an empty database, or
+// only recorded on one that already has all the migrations it replaces.
+func baselineAction(baseline Migration, inDB []Migration) (Action, error) {
+	if len(inDB) == 0 {
+		return ActionMigrate, nil
+	}
+
+	var missing []string
+	for _, name := range baseline.Supersedes() {
+		if !slices.ContainsFunc(inDB, func(m Migration) bool { return m.Name == name }) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return 0, fmt.Errorf("migrator: %s replaces migrations that were never applied here, "+
+			"apply them with a release from before the squash first: %s",
+			baseline.Name, strings.Join(missing, ", "))
+	}
+	return ActionBaseline, nil
+}
+
+func headerLines(sql string) (lines []string) {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		} else if !strings.HasPrefix(line, "--") {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func hasMarker(sql, marker string) bool {
+	return slices.ContainsFunc(headerLines(sql), func(line string) bool {
+		return strings.HasPrefix(line, marker)
+	})
+}
+
+// Squash dumps the schema of the fully migrated database at dbURL into a new baseline
+// migration named name, replacing every migration applied so far. The baseline is
+// returned for the caller to write out and then record with ActionBaseline.
+func (m *Migrator) Squash(ctx context.Context, dbURL, name string) (Migration, error) {
+	plans, dirty, err := m.Plan(ctx, IntentMigrate)
+	if err != nil {
+		return Migration{}, err
+	} else if dirty || len(plans) > 0 {
+		return Migration{}, fmt.Errorf("migrator: database has pending or changed migrations, migrate it before squashing")
+	}
+
+	inDB, err := Load(FromDB(ctx, m.db))
+	if err != nil {
+		return Migration{}, err
+	}
+
+	var names []string
+	for _, mig := range activeMigrations(inDB) {
+		names = append(names, mig.Name)
+	}
+	if len(names) == 0 {
+		return Migration{}, ErrNoMigrations
+	}
+
+	schema, err := DumpSchema(ctx, dbURL)
+	if err != nil {
+		return Migration{}, err
+	}
+	return NewBaseline(name, schema, names), nil
+}
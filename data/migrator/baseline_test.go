@@ -0,0 +1,149 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBaseline(t *testing.T) {
+	baseline := NewBaseline("202501010000_baseline", "CREATE TABLE t ();", []string{"1_a", "2_b"})
+	require.True(t, baseline.IsBaseline())
+	require.Equal(t, []string{"1_a", "2_b"}, baseline.Supersedes())
+	require.False(t, NoTransaction(baseline.UpSQL))
+	require.False(t, Migration{UpSQL: "CREATE TABLE t ();\n-- fx:baseline"}.IsBaseline())
+}
+
+func TestCleanDump(t *testing.T) {
+	dump := `--
+-- PostgreSQL database dump
+--
+\restrict abc
+
+SET statement_timeout = 0;
+SET client_encoding = 'UTF8';
+SELECT pg_catalog.set_config('search_path', '', false);
+
+
+CREATE TABLE public.todos (
+    id integer NOT NULL
+);
+\unrestrict abc
+`
+	require.Equal(t, "--\n-- PostgreSQL database dump\n--\n\nCREATE TABLE public.todos (\n    id integer NOT NULL\n);\n", cleanDump(dump))
+
+	// function bodies and other quoted text are kept as they are
+	dump = `SET check_function_bodies = false;
+
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $_$
+BEGIN
+SET LOCAL search_path = public;
+
+
+\echo in a body
+RETURN NEW;
+END;
+$_$;
+
+COMMENT ON TABLE public.todos IS 'it''s
+SET in a comment';
+SET default_table_access_method = heap;
+`
+	require.Equal(t, `CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $_$
+BEGIN
+SET LOCAL search_path = public;
+
+
+\echo in a body
+RETURN NEW;
+END;
+$_$;
+
+COMMENT ON TABLE public.todos IS 'it''s
+SET in a comment';
+`, cleanDump(dump))
+}
+
+func TestDumpQuoteEnd(t *testing.T) {
+	require.Equal(t, "", dumpQuoteEnd("SET x = 'a'; -- it's", ""))
+	require.Equal(t, "$_$", dumpQuoteEnd("    AS $_$", ""))
+	require.Equal(t, "", dumpQuoteEnd("$_$;", "$_$"))
+	require.Equal(t, "'", dumpQuoteEnd("COMMENT ON TABLE t IS 'it''s", ""))
+	require.Equal(t, "*/", dumpQuoteEnd(`/* "x" */ SELECT 1; /* more`, ""))
+	require.Equal(t, "$body$", dumpQuoteEnd("still inside $$ and ' quotes", "$body$"))
+}
+
+func TestPlanBaseline(t *testing.T) {
+	var (
+		m        Migrator
+		old      = []Migration{{Name: "1_a", UpSQL: "A"}, {Name: "2_b", UpSQL: "B"}}
+		baseline = NewBaseline("3_baseline", "A; B;", []string{"1_a", "2_b"})
+		after    = Migration{Name: "4_c", UpSQL: "C"}
+		inFiles  = fromBaseline(append(old, baseline, after))
+	)
+	require.Equal(t, []Migration{baseline, after}, inFiles)
+
+	// empty database starts from the baseline
+	plans, dirty, err := m.planMigrate(inFiles, nil)
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, []Plan{{ActionMigrate, baseline}, {ActionMigrate, after}}, plans)
+
+	// existing database only records it
+	plans, dirty, err = m.planMigrate(inFiles, old)
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, []Plan{{ActionBaseline, baseline}, {ActionMigrate, after}}, plans)
+
+	// databases missing squashed migrations cannot take the baseline
+	_, _, err = m.planMigrate(inFiles, old[:1])
+	require.ErrorContains(t, err, "2_b")
+
+	// superseded rows are ignored
+	recorded := []Migration{
+		{Name: "1_a", UpSQL: "A", SupersededBy: "3_baseline"},
+		{Name: "2_b", UpSQL: "B", SupersededBy: "3_baseline"},
+		baseline,
+	}
+	plans, dirty, err = m.planMigrate(inFiles, activeMigrations(recorded))
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, []Plan{{ActionMigrate, after}}, plans)
+	require.Equal(t, StateSuperseded, statuses(inFiles, recorded)[0].State)
+}
+
+func TestPlanBaseline_Namespaces(t *testing.T) {
+	var (
+		m        Migrator
+		users    = Migration{Name: "202401010000_users", UpSQL: "A"}
+		audit    = Migration{Name: "202402010000_audit", Namespace: "audit", UpSQL: "B"}
+		baseline = NewBaseline("202405010000_baseline", "A; B;", []string{users.Name, audit.Name})
+
+		// shipped by a later release of the audit fragment, but dated before the baseline
+		auditNew = Migration{Name: "202403010000_audit_index", Namespace: "audit", UpSQL: "C"}
+	)
+
+	inFiles := fromBaseline([]Migration{users, audit, auditNew, baseline})
+	require.Equal(t, []Migration{auditNew, baseline}, inFiles)
+
+	// empty databases run the baseline first, the new migration builds on its schema
+	plans, dirty, err := planByNamespace(inFiles, nil, m.planMigrate)
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, []Plan{{ActionMigrate, baseline}, {ActionMigrate, auditNew}}, plans)
+
+	// squashed databases only need the new migration
+	recorded := resolveNamespaces(inFiles, activeMigrations([]Migration{
+		{Name: users.Name, UpSQL: "A", SupersededBy: baseline.Name},
+		{Name: audit.Name, UpSQL: "B", Namespace: "audit", SupersededBy: baseline.Name},
+		baseline,
+	}))
+	plans, dirty, err = planByNamespace(inFiles, recorded, m.planMigrate)
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, []Plan{{ActionMigrate, auditNew}}, plans)
+}
@@ -0,0 +1,96 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// GoMigrationSQL is what gets recorded in the migrations table in place of the SQL of a
+// Go migration, see Register.
+const GoMigrationSQL = "-- fx:go-migration"
+
+// Func is the body of a Go migration. The context carries the migration's transaction so
+// that data.Get, data.Exec etc. called with it run inside that transaction.
+type Func func(ctx context.Context) error
+
+var goMigrations []Migration
+
+// Register adds a migration implemented in Go, for data migrations that need more than
+// SQL, e.g. backfilling encrypted columns. Like SQL migrations, name should start with
+// a timestamp so it is ordered along with them, and down may be nil for migrations that
+// cannot be rolled back. Call it from an init function:
+//
+//	func init() {
+//		migrator.Register("202501021504_encrypt_phone_numbers", encryptPhones, nil)
+//	}
+//
+// Go migrations are included by LoadAuto and run inside the same transaction that
+// records them in the migrations table. Register panics on duplicate names.
+func Register(name string, up, down Func) {
+	if name == "" || up == nil {
+		panic("migrator: Register requires a name and an up function")
+	}
+	if slices.ContainsFunc(goMigrations, func(m Migration) bool { return m.Name == name }) {
+		panic("migrator: duplicate go migration " + name)
+	}
+
+	dir := ""
+	if _, file, _, ok := runtime.Caller(1); ok {
+		dir = filepath.Dir(file)
+	}
+
+	goMigrations = append(goMigrations, Migration{
+		Name:    name,
+		Dir:     dir,
+		UpSQL:   GoMigrationSQL,
+		DownSQL: GoMigrationSQL,
+		Up:      up,
+		Down:    down,
+	})
+}
+
+// FromGo is a Source yielding all migrations added with Register.
+func FromGo() Source {
+	return func() ([]Migration, error) { return slices.Clone(goMigrations), nil }
+}
+
+// IsGo reports whether m is a Go migration, either registered or as recorded in the
+// database.
+func (m Migration) IsGo() bool {
+	return m.Up != nil || strings.HasPrefix(m.UpSQL, GoMigrationSQL)
+}
+
+// withGoMigrations merges the registered Go migrations into migrations, keeping them
+// ordered by name.
+func withGoMigrations(migrations []Migration) ([]Migration, error) {
+	if len(goMigrations) == 0 {
+		return migrations, nil
+	}
+
+	all := append(slices.Clone(migrations), goMigrations...)
+	slices.SortStableFunc(all, func(a, b Migration) int { return strings.Compare(a.Name, b.Name) })
+	for idx := 1; idx < len(all); idx++ {
+		if all[idx].Name == all[idx-1].Name {
+			return nil, fmt.Errorf("migrator: duplicate migration %s", all[idx].Name)
+		}
+	}
+	return all, nil
+}
+
+// withGoFuncs attaches the registered Go functions to migrations loaded from the database,
+// which only record their name.
+func withGoFuncs(mig Migration) Migration {
+	if !mig.IsGo() || mig.Up != nil {
+		return mig
+	}
+
+	idx := slices.IndexFunc(goMigrations, func(m Migration) bool { return m.Name == mig.Name })
+	if idx >= 0 {
+		mig.Up, mig.Down = goMigrations[idx].Up, goMigrations[idx].Down
+	}
+	return mig
+}
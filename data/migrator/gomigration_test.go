@@ -0,0 +1,44 @@
+package migrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	saved := goMigrations
+	t.Cleanup(func() { goMigrations = saved })
+	goMigrations = nil
+
+	up := func(ctx context.Context) error { return nil }
+	Register("2_backfill", up, nil)
+	require.Panics(t, func() { Register("2_backfill", up, nil) })
+
+	all, err := withGoMigrations([]Migration{
+		{Name: "1_create", UpSQL: "CREATE TABLE t ()"},
+		{Name: "3_alter", UpSQL: "ALTER TABLE t ADD c int"},
+	})
+	require.NoError(t, err)
+
+	var names []string
+	for _, mig := range all {
+		names = append(names, mig.Name)
+	}
+	require.Equal(t, []string{"1_create", "2_backfill", "3_alter"}, names)
+	require.True(t, all[1].IsGo())
+	require.False(t, all[0].IsGo())
+	require.Contains(t, all[1].Dir, "migrator")
+
+	_, err = withGoMigrations([]Migration{{Name: "2_backfill"}})
+	require.Error(t, err)
+
+	recorded := withGoFuncs(Migration{Name: "2_backfill", UpSQL: GoMigrationSQL})
+	require.NotNil(t, recorded.Up)
+	require.Nil(t, recorded.Down)
+
+	unknown := withGoFuncs(Migration{Name: "9_unknown", UpSQL: GoMigrationSQL})
+	require.True(t, unknown.IsGo())
+	require.Nil(t, unknown.Up)
+}
@@ -0,0 +1,215 @@
+package migrator
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// LintIgnoreMarker allows a lint rule for the statement it is attached to (the comments
+// directly above it or inside it), e.g. `-- fx:lint-ignore drop-column` above an
+// intentional DROP COLUMN. Several rules may be given, separated by commas or spaces.
+// For empty-down, put it in the down file.
+const LintIgnoreMarker = "-- fx:lint-ignore "
+
+type Severity int
+
+const (
+	SeverityWarning = Severity(iota)
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "(unknown)"
+	}
+}
+
+// Lint rules, also used in LintIgnoreMarker comments.
+const (
+	RuleNotNullWithoutDefault = "not-null-without-default"
+	RuleIndexNotConcurrent    = "index-not-concurrent"
+	RuleConcurrentInTx        = "concurrently-in-transaction"
+	RuleDropColumn            = "drop-column"
+	RuleAlterType             = "alter-type"
+	RuleEmptyDown             = "empty-down"
+)
+
+// Finding is a single lint result. Line is the line in the up file where the offending
+// statement starts, or 0 for findings about the whole migration.
+type Finding struct {
+	Migration string
+	Line      int
+	Severity  Severity
+	Rule      string
+	Message   string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (%s)", f.Migration, f.Line, f.Severity, f.Message, f.Rule)
+}
+
+var (
+	createTableRx = regexp.MustCompile(`(?i)^CREATE\s+(?:UNLOGGED\s+|TEMP(?:ORARY)?\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`)
+	alterTableRx  = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?[\w."]+\s+(.*)$`)
+	createIndexRx = regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?.*?\bON\s+(?:ONLY\s+)?([\w."]+)`)
+	addColumnRx   = regexp.MustCompile(`(?i)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?`)
+	addOtherRx    = regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK|EXCLUDE)\b`)
+	notNullRx     = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	defaultRx     = regexp.MustCompile(`(?i)\b(?:DEFAULT|GENERATED)\b`)
+	dropColumnRx  = regexp.MustCompile(`(?i)^DROP\s+COLUMN\b`)
+	alterTypeRx   = regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?[\w"]+\s+(?:SET\s+DATA\s+)?TYPE\b`)
+)
+
+// Lint statically inspects a migration for operations that are risky on large, busy
+// tables. It only looks at the SQL text, so findings are heuristics rather than
+// certainties. Go migrations and baselines are not linted.
+func Lint(mig Migration) (findings []Finding) {
+	if mig.IsGo() || mig.IsBaseline() {
+		return nil
+	}
+
+	report := func(line int, severity Severity, rule, msg string, ignored []string) {
+		if !slices.Contains(ignored, rule) {
+			findings = append(findings, Finding{mig.Name, line, severity, rule, msg})
+		}
+	}
+
+	if strings.TrimSpace(stripComments(mig.DownSQL)) == "" {
+		report(0, SeverityError, RuleEmptyDown, "down migration is empty", lintIgnores(mig.DownSQL))
+	}
+
+	var (
+		noTx    = NoTransaction(mig.UpSQL)
+		created []string
+		offset  = 0
+	)
+	for _, stmt := range splitStatements(mig.UpSQL) {
+		line := 1
+		if pos := strings.Index(mig.UpSQL[offset:], stmt); pos >= 0 {
+			offset += pos
+			line = strings.Count(mig.UpSQL[:offset], "\n") + 1
+		}
+
+		var (
+			ignored = lintIgnores(stmt)
+			sql     = normalizeSQL(stripComments(stmt))
+		)
+		if strings.HasPrefix(stmt, "--") {
+			// the statement's leading comments, report where the SQL itself starts
+			line += strings.Count(stmt[:strings.Index(stmt, firstWord(sql))], "\n")
+		}
+
+		if m := createTableRx.FindStringSubmatch(sql); m != nil {
+			created = append(created, unquoteIdent(m[1]))
+		}
+
+		if m := createIndexRx.FindStringSubmatch(sql); m != nil {
+			switch {
+			case m[1] != "" && !noTx:
+				report(line, SeverityError, RuleConcurrentInTx,
+					"CREATE INDEX CONCURRENTLY cannot run in a transaction, add the "+NoTransactionMarker+" marker", ignored)
+			case m[1] == "" && !slices.Contains(created, unquoteIdent(m[2])):
+				report(line, SeverityWarning, RuleIndexNotConcurrent,
+					"CREATE INDEX locks "+m[2]+" against writes, consider CREATE INDEX CONCURRENTLY", ignored)
+			}
+		}
+
+		m := alterTableRx.FindStringSubmatch(sql)
+		if m == nil {
+			continue
+		}
+		for _, clause := range splitClauses(m[1]) {
+			switch {
+			case addOtherRx.MatchString(clause):
+				continue
+			case addColumnRx.MatchString(clause):
+				if notNullRx.MatchString(clause) && !defaultRx.MatchString(clause) {
+					report(line, SeverityError, RuleNotNullWithoutDefault,
+						"adding a NOT NULL column without a DEFAULT fails on tables with rows", ignored)
+				}
+			case dropColumnRx.MatchString(clause):
+				report(line, SeverityWarning, RuleDropColumn,
+					"DROP COLUMN breaks code still reading the column, deploy the code change first", ignored)
+			case alterTypeRx.MatchString(clause):
+				report(line, SeverityWarning, RuleAlterType,
+					"changing a column type may rewrite the table under an exclusive lock", ignored)
+			}
+		}
+	}
+	return findings
+}
+
+// lintIgnores lists the rules allowed by LintIgnoreMarker comments in sql.
+func lintIgnores(sql string) (rules []string) {
+	for _, line := range strings.Split(sql, "\n") {
+		_, after, ok := strings.Cut(line, LintIgnoreMarker)
+		if !ok {
+			continue
+		}
+		rules = append(rules, strings.FieldsFunc(after, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})...)
+	}
+	return rules
+}
+
+// stripComments removes -- and /* */ comments, leaving quoted strings alone.
+func stripComments(sql string) string {
+	sb := &strings.Builder{}
+	for idx := 0; idx < len(sql); {
+		switch rest := sql[idx:]; {
+		case strings.HasPrefix(rest, "--"):
+			idx += skipUntil(rest, "\n", 2)
+			sb.WriteByte('\n')
+		case strings.HasPrefix(rest, "/*"):
+			idx += skipUntil(rest, "*/", 2)
+			sb.WriteByte(' ')
+		case rest[0] == '\'' || rest[0] == '"':
+			n := skipUntil(rest, rest[:1], 1)
+			sb.WriteString(rest[:n])
+			idx += n
+		default:
+			sb.WriteByte(rest[0])
+			idx++
+		}
+	}
+	return sb.String()
+}
+
+// splitClauses splits the actions of an ALTER TABLE on commas outside of parentheses.
+func splitClauses(sql string) (clauses []string) {
+	depth, start := 0, 0
+	for idx, c := range sql {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				clauses = append(clauses, strings.TrimSpace(sql[start:idx]))
+				start = idx + 1
+			}
+		}
+	}
+	return append(clauses, strings.TrimSpace(sql[start:]))
+}
+
+func unquoteIdent(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, `"`, ""))
+	return strings.TrimPrefix(name, "public.")
+}
+
+func firstWord(sql string) string {
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		return fields[0]
+	}
+	return sql
+}
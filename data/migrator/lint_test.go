@@ -0,0 +1,68 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	rules := func(up, down string) (rules []string) {
+		for _, finding := range Lint(Migration{Name: "1_test", UpSQL: up, DownSQL: down}) {
+			rules = append(rules, finding.Rule)
+		}
+		return rules
+	}
+
+	require.Empty(t, rules(`
+CREATE TABLE todos (id serial PRIMARY KEY, title text NOT NULL);
+CREATE INDEX todos_title ON todos (title);
+ALTER TABLE users ADD COLUMN age int, ADD COLUMN role text NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD CONSTRAINT users_age CHECK (age > 0 AND age IS NOT NULL);`,
+		"DROP TABLE todos;"))
+
+	require.Equal(t, []string{RuleEmptyDown}, rules("SELECT 1;", "-- nothing to do\n"))
+	require.Empty(t, rules("SELECT 1;", "-- fx:lint-ignore empty-down\n"))
+
+	require.Equal(t, []string{RuleNotNullWithoutDefault},
+		rules("ALTER TABLE users ADD COLUMN role text NOT NULL;", "SELECT 1"))
+	require.Equal(t, []string{RuleIndexNotConcurrent},
+		rules("CREATE UNIQUE INDEX users_email ON public.users (email);", "SELECT 1"))
+	require.Equal(t, []string{RuleConcurrentInTx},
+		rules("CREATE INDEX CONCURRENTLY users_email ON users (email);", "SELECT 1"))
+	require.Empty(t, rules("-- fx:no-transaction\nCREATE INDEX CONCURRENTLY users_email ON users (email);", "SELECT 1"))
+	require.Equal(t, []string{RuleDropColumn, RuleAlterType},
+		rules("ALTER TABLE users DROP COLUMN age, ALTER COLUMN name TYPE varchar(100);", "SELECT 1"))
+	require.Equal(t, []string{RuleAlterType},
+		rules("ALTER TABLE users ALTER name SET DATA TYPE text;", "SELECT 1"))
+
+	require.Equal(t, []string{RuleAlterType}, rules(`
+-- fx:lint-ignore drop-column
+ALTER TABLE users DROP COLUMN age;
+ALTER TABLE users ALTER COLUMN name TYPE text; -- fx:lint-ignore drop-column
+/* fx:lint-ignore only works in line comments */`, "SELECT 1"))
+	require.Empty(t, rules(`
+-- fx:lint-ignore drop-column, alter-type
+ALTER TABLE users DROP COLUMN age, ALTER COLUMN name TYPE text;`, "SELECT 1"))
+
+	require.Empty(t, Lint(Migration{Name: "1_go", UpSQL: GoMigrationSQL}))
+}
+
+func TestLint_Lines(t *testing.T) {
+	findings := Lint(Migration{
+		Name: "1_test",
+		UpSQL: `CREATE TABLE todos (id serial);
+
+-- drop the old column
+ALTER TABLE users
+  DROP COLUMN age;
+ALTER TABLE users DROP COLUMN name;`,
+		DownSQL: "",
+	})
+
+	require.Len(t, findings, 3)
+	require.Equal(t, "1_test:0: error: down migration is empty (empty-down)", findings[0].String())
+	require.Equal(t, 4, findings[1].Line)
+	require.Equal(t, 6, findings[2].Line)
+	require.Equal(t, SeverityWarning, findings[2].Severity)
+}
@@ -0,0 +1,45 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fx.prodigy9.co/config"
+	"fx.prodigy9.co/data"
+)
+
+// LockKey names the advisory lock held while migrations are planned and applied.
+const LockKey = "fx.prodigy9.co/data/migrator"
+
+var (
+	// LockTimeoutConfig is how long to wait for another migrator to finish before giving
+	// up with ErrLocked. Zero waits indefinitely.
+	LockTimeoutConfig = config.DurationDef("DATABASE_MIGRATIONS_LOCK_TIMEOUT", 1*time.Minute)
+
+	ErrLocked = errors.New("migrator: migrations are being run by another process")
+)
+
+// Lock runs action while holding a cluster-wide advisory lock so that concurrent
+// migrators, e.g. several pods running `data migrate` at boot, take turns instead of
+// applying the same migrations twice. Plan and Apply should both be called inside action
+// so the plan cannot go stale before it is applied.
+//
+// The lock is held on a dedicated connection for as long as action runs. If it cannot be
+// acquired within timeout, Lock returns an error matching ErrLocked.
+func (m *Migrator) Lock(ctx context.Context, timeout time.Duration, action func(ctx context.Context) error) error {
+	ran := false
+	err := data.WithAdvisoryLock(data.NewContext(ctx, m.db), LockKey, data.LockOptions{
+		Timeout: timeout,
+		Session: true,
+	}, func(ctx context.Context) error {
+		ran = true
+		return action(ctx)
+	})
+
+	if !ran && errors.Is(err, data.ErrLockTimeout) {
+		return fmt.Errorf("%w, gave up after waiting %s for it to finish", ErrLocked, timeout)
+	}
+	return err
+}
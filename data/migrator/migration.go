@@ -1,8 +1,14 @@
 package migrator
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/user"
 	"path/filepath"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/chakrit/gendiff"
 	"github.com/gobuffalo/flect"
@@ -10,10 +16,134 @@ import (
 )
 
 type Migration struct {
-	Name    string `db:"name"`
-	Dir     string `db:"-"`
-	UpSQL   string `db:"up_sql"`
-	DownSQL string `db:"down_sql"`
+	Name      string `db:"name"`
+	Namespace string `db:"namespace"`
+	Dir       string `db:"-"`
+	UpSQL     string `db:"up_sql"`
+	DownSQL   string `db:"down_sql"`
+
+	// Checksum, AppliedAt and AppliedBy are only set for migrations loaded from the
+	// database, and may be empty for rows recorded before they were tracked.
+	Checksum  string     `db:"checksum"`
+	AppliedAt *time.Time `db:"applied_at"`
+	AppliedBy string     `db:"applied_by"`
+
+	// SupersededBy names the baseline that replaced this migration, see NewBaseline.
+	SupersededBy string `db:"superseded_by"`
+
+	// Up and Down are set for Go migrations instead of UpSQL and DownSQL, see Register.
+	Up   Func `db:"-"`
+	Down Func `db:"-"`
+}
+
+// Sum returns the checksum used to tell whether a migration has changed, the stored one
+// if there is one, otherwise computed with Checksum.
+func (m Migration) Sum() string {
+	if m.Checksum != "" {
+		return m.Checksum
+	}
+	return Checksum(m.UpSQL, m.DownSQL)
+}
+
+// Checksum hashes a migration's SQL after collapsing whitespace, so re-indenting or
+// re-wrapping a migration file does not count as a change. Whitespace inside quoted
+// strings and identifiers is kept, since changing it changes the data or the schema.
+func Checksum(upSQL, downSQL string) string {
+	return checksumWith(normalizeSQL, upSQL, downSQL)
+}
+
+// legacyChecksum is Checksum as it was before it kept quoted whitespace intact, which is
+// what rows recorded back then hold.
+func legacyChecksum(upSQL, downSQL string) string {
+	return checksumWith(func(sql string) string {
+		return strings.Join(strings.Fields(sql), " ")
+	}, upSQL, downSQL)
+}
+
+func checksumWith(normalize func(string) string, upSQL, downSQL string) string {
+	h := sha256.New()
+	h.Write([]byte(normalize(upSQL)))
+	h.Write([]byte{0})
+	h.Write([]byte(normalize(downSQL)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// unchanged reports whether mFile still matches mDB as recorded in the database. Rows
+// recorded with the legacy checksum are compared by hashing their recorded SQL again, so
+// that they neither look modified nor hide changes inside quoted strings.
+func unchanged(mDB, mFile Migration) bool {
+	sum := mDB.Sum()
+	if mDB.Checksum != "" && mDB.Checksum == legacyChecksum(mDB.UpSQL, mDB.DownSQL) {
+		// the legacy sum of 'a  b' is the current sum of 'a b', so never compare it directly
+		sum = Checksum(mDB.UpSQL, mDB.DownSQL)
+	}
+	return sum == mFile.Sum()
+}
+
+// normalizeSQL collapses runs of whitespace into single spaces, except inside quoted
+// strings and identifiers. Comments and dollar-quoted bodies, usually function
+// definitions, are scanned like the rest of the code. See splitStatements.
+func normalizeSQL(sql string) string {
+	var (
+		sb    = &strings.Builder{}
+		start = 0
+		idx   = 0
+	)
+
+	code := func(end int) {
+		segment := sql[start:end]
+		if fields := strings.Fields(segment); len(fields) == 0 {
+			if segment != "" {
+				sb.WriteByte(' ')
+			}
+		} else {
+			if strings.TrimLeftFunc(segment, unicode.IsSpace) != segment {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(strings.Join(fields, " "))
+			if strings.TrimRightFunc(segment, unicode.IsSpace) != segment {
+				sb.WriteByte(' ')
+			}
+		}
+		start = end
+	}
+
+	for idx < len(sql) {
+		switch rest := sql[idx:]; {
+		case strings.HasPrefix(rest, "--"):
+			idx += skipUntil(rest, "\n", 2)
+		case strings.HasPrefix(rest, "/*"):
+			idx += skipUntil(rest, "*/", 2)
+		case rest[0] == '\'' || rest[0] == '"':
+			code(idx)
+			idx += skipUntil(rest, rest[:1], 1)
+			sb.WriteString(sql[start:idx])
+			start = idx
+		case rest[0] == '$':
+			if tag := dollarTag(rest); tag != "" {
+				idx += len(tag)
+			} else {
+				idx++
+			}
+		default:
+			idx++
+		}
+	}
+
+	code(len(sql))
+	return strings.TrimSpace(sb.String())
+}
+
+// appliedBy identifies who is applying migrations, as user@host.
+func appliedBy() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + host
+	}
+	return name
 }
 
 type migrationDiff struct {
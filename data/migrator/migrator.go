@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"fx.prodigy9.co/config"
 	"fx.prodigy9.co/data"
 	"github.com/chakrit/gendiff"
 	"github.com/jmoiron/sqlx"
@@ -20,21 +21,56 @@ const (
 	CreateMigrationsTableSQL = `
 		CREATE TABLE IF NOT EXISTS migrations
 		(
-			name     text PRIMARY KEY,
-			up_sql   text NOT NULL,
-			down_sql text NOT NULL
+			name          text PRIMARY KEY,
+			namespace     text,
+			up_sql        text NOT NULL,
+			down_sql      text NOT NULL,
+			checksum      text,
+			applied_at    timestamptz,
+			applied_by    text,
+			superseded_by text
 		);`
 
+	UpgradeMigrationsTableSQL = `
+		ALTER TABLE migrations
+			ADD COLUMN IF NOT EXISTS namespace text,
+			ADD COLUMN IF NOT EXISTS checksum text,
+			ADD COLUMN IF NOT EXISTS applied_at timestamptz,
+			ADD COLUMN IF NOT EXISTS applied_by text,
+			ADD COLUMN IF NOT EXISTS superseded_by text;`
+
+	// reads the metadata columns through to_jsonb so that listing still works against
+	// tables created before they were added, which are only upgraded by Apply.
 	ListMigrationsSQL = `
-		SELECT *
-		FROM migrations
-		ORDER BY name ASC`
+		SELECT
+			m.name,
+			COALESCE(j ->> 'namespace', '') AS namespace,
+			m.up_sql,
+			m.down_sql,
+			COALESCE(j ->> 'checksum', '') AS checksum,
+			(j ->> 'applied_at')::timestamptz AS applied_at,
+			COALESCE(j ->> 'applied_by', '') AS applied_by,
+			COALESCE(j ->> 'superseded_by', '') AS superseded_by
+		FROM migrations m, to_jsonb(m) j
+		ORDER BY m.name ASC`
 
 	UpdateMigrationSQL = `
-		INSERT INTO migrations (name, up_sql, down_sql)
-		VALUES ($1, $2, $3)
+		INSERT INTO migrations (name, up_sql, down_sql, checksum, namespace)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name) DO UPDATE
+		SET up_sql = $2, down_sql = $3, checksum = $4, namespace = $5`
+
+	RecordMigrationSQL = `
+		INSERT INTO migrations (name, up_sql, down_sql, checksum, applied_at, applied_by, namespace)
+		VALUES ($1, $2, $3, $4, now(), $5, $6)
 		ON CONFLICT (name) DO UPDATE
-		SET up_sql = $2, down_sql = $3`
+		SET up_sql = $2, down_sql = $3, checksum = $4, applied_at = now(), applied_by = $5,
+			namespace = $6`
+
+	SupersedeMigrationsSQL = `
+		UPDATE migrations
+		SET superseded_by = $1
+		WHERE name = ANY($2) AND superseded_by IS NULL`
 
 	PruneMigrationSQL = `
 		DELETE FROM migrations
@@ -48,15 +84,56 @@ const (
 )
 
 type Migrator struct {
-	db  *sqlx.DB
-	src Source
+	db        *sqlx.DB
+	src       Source
+	namespace *string
 }
 
 func New(db *sqlx.DB, src Source) *Migrator {
-	return &Migrator{db, src}
+	return &Migrator{db: db, src: src}
+}
+
+// MigrateAuto applies every pending migration found by LoadAuto to the database in ctx
+// while holding the migration lock, without prompting. ctx must also carry the config.
+// Having no migrations at all is not an error, dirty migrations are. It is mostly useful
+// for setting up test databases, e.g. with fxtest.ConnectTxDatabase.
+func MigrateAuto(ctx context.Context) error {
+	var (
+		cfg = config.FromContext(ctx)
+		m   = New(data.FromContext(ctx), FromAuto(cfg))
+	)
+	return m.Lock(ctx, config.Get(cfg, LockTimeoutConfig), func(ctx context.Context) error {
+		plans, dirty, err := m.Plan(ctx, IntentMigrate)
+		if IsNoMigrations(err) {
+			return nil
+		} else if err != nil {
+			return err
+		} else if dirty {
+			return fmt.Errorf("migrator: migrations are missing or have changed content")
+		}
+
+		for _, plan := range plans {
+			if err := m.Apply(ctx, plan); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (m *Migrator) Plan(ctx context.Context, intent Intent) (actions []Plan, dirty bool, err error) {
+	return m.PlanTo(ctx, intent, "")
+}
+
+// PlanTo is Plan for IntentMigrateTo and IntentRollbackTo, which need a target. The
+// target is either a migration name (or just its timestamp prefix) or a number of
+// migrations:
+//
+//   - IntentMigrateTo applies pending migrations up to and including the named one, or
+//     the next N pending migrations.
+//   - IntentRollbackTo rolls back every migration applied after the named one, which
+//     stays applied, or the last N applied migrations.
+func (m *Migrator) PlanTo(ctx context.Context, intent Intent, target string) (actions []Plan, dirty bool, err error) {
 	var (
 		scope   data.Scope
 		inFiles []Migration
@@ -77,13 +154,30 @@ func (m *Migrator) Plan(ctx context.Context, intent Intent) (actions []Plan, dir
 		}
 	}
 
+	inDB = resolveNamespaces(inFiles, activeMigrations(inDB))
+	if m.namespace != nil {
+		inFiles, inDB = inNamespace(inFiles, *m.namespace), inNamespace(inDB, *m.namespace)
+	}
+
 	switch intent {
 	case IntentResync:
-		return m.planResync(inFiles, inDB)
+		return planByNamespace(inFiles, inDB, m.planResync)
 	case IntentMigrate:
-		return m.planMigrate(inFiles, inDB)
+		return planByNamespace(inFiles, inDB, m.planMigrate)
 	case IntentRollback:
-		return m.planRollback(inFiles, inDB)
+		return m.planRollback(inFiles, inDB, min(MaxRollbacks, len(inDB)))
+	case IntentMigrateTo:
+		if actions, dirty, err = planByNamespace(inFiles, inDB, m.planMigrate); err != nil {
+			return
+		}
+		actions, err = migrateTo(actions, target)
+		return
+	case IntentRollbackTo:
+		var count int
+		if count, err = rollbackCount(inDB, target); err != nil {
+			return
+		}
+		return m.planRollback(inFiles, inDB, count)
 	default:
 		return nil, false, nil
 	}
@@ -91,20 +185,23 @@ func (m *Migrator) Plan(ctx context.Context, intent Intent) (actions []Plan, dir
 
 func (m *Migrator) planResync(inFiles []Migration, inDB []Migration) (actions []Plan, dirty bool, err error) {
 	diffs := gendiff.Make(migrationDiff{inDB, inFiles})
+	superseded := pendingSupersedes(inFiles)
 
 	for _, d := range diffs {
 		switch d.Op {
 		case gendiff.Delete:
-			dirty = true
 			for lidx := d.Lstart; lidx < d.Lend; lidx++ {
-				actions = append(actions, Plan{ActionPrune, inDB[lidx]})
+				if !superseded[inDB[lidx].Name] {
+					dirty = true
+					actions = append(actions, Plan{ActionPrune, inDB[lidx]})
+				}
 			}
 
 		case gendiff.Match:
 			lidx, ridx := d.Lstart, d.Rstart
 			for lidx < d.Lend && ridx < d.Rend {
 				mDB, mFile := inDB[lidx], inFiles[ridx]
-				if mDB.UpSQL != mFile.UpSQL || mDB.DownSQL != mFile.DownSQL {
+				if !unchanged(mDB, mFile) {
 					dirty = true
 					actions = append(actions, Plan{ActionResync, mFile})
 				}
@@ -119,6 +216,7 @@ func (m *Migrator) planResync(inFiles []Migration, inDB []Migration) (actions []
 
 func (m *Migrator) planMigrate(inFiles []Migration, inDB []Migration) (actions []Plan, dirty bool, err error) {
 	diffs := gendiff.Make(migrationDiff{inDB, inFiles})
+	superseded := pendingSupersedes(inFiles)
 
 	for _, d := range diffs {
 		switch d.Op {
@@ -130,20 +228,28 @@ func (m *Migrator) planMigrate(inFiles []Migration, inDB []Migration) (actions [
 				return
 			}
 			for ridx := d.Rstart; ridx < d.Rend; ridx++ {
-				actions = append(actions, Plan{ActionMigrate, inFiles[ridx]})
+				action := ActionMigrate
+				if inFiles[ridx].IsBaseline() {
+					if action, err = baselineAction(inFiles[ridx], inDB); err != nil {
+						return
+					}
+				}
+				actions = append(actions, Plan{action, inFiles[ridx]})
 			}
 
 		case gendiff.Delete:
-			dirty = true
 			for lidx := d.Lstart; lidx < d.Lend; lidx++ {
-				actions = append(actions, Plan{ActionPrune, inDB[lidx]})
+				if !superseded[inDB[lidx].Name] {
+					dirty = true
+					actions = append(actions, Plan{ActionPrune, inDB[lidx]})
+				}
 			}
 
 		case gendiff.Match:
 			lidx, ridx := d.Lstart, d.Rstart
 			for lidx < d.Lend && ridx < d.Rend {
 				mDB, mFile := inDB[lidx], inFiles[ridx]
-				if mDB.UpSQL != mFile.UpSQL || mDB.DownSQL != mFile.DownSQL {
+				if !unchanged(mDB, mFile) {
 					dirty = true
 					actions = append(actions, Plan{ActionResync, mFile})
 				}
@@ -156,8 +262,11 @@ func (m *Migrator) planMigrate(inFiles []Migration, inDB []Migration) (actions [
 	return
 }
 
-func (m *Migrator) planRollback(inFiles []Migration, inDB []Migration) (actions []Plan, dirty bool, err error) {
-	rollbackIdx := len(inDB) - MaxRollbacks
+func (m *Migrator) planRollback(inFiles []Migration, inDB []Migration, count int) (actions []Plan, dirty bool, err error) {
+	if count == 0 {
+		return
+	}
+	rollbackIdx := len(inDB) - count
 
 	diffs := gendiff.Make(migrationDiff{inDB, inFiles})
 	for _, d := range diffs {
@@ -182,7 +291,7 @@ func (m *Migrator) planRollback(inFiles []Migration, inDB []Migration) (actions
 			lidx, ridx := d.Lstart, d.Rstart
 			for lidx < d.Lend && ridx < d.Rend {
 				mDB, mFile := inDB[lidx], inFiles[ridx]
-				if mDB.UpSQL != mFile.UpSQL || mDB.DownSQL != mFile.DownSQL {
+				if !unchanged(mDB, mFile) {
 					dirty = true
 					if d.Lstart <= rollbackIdx {
 						err = fmt.Errorf("db state divergence detected, please carefully review and re-sync")
@@ -198,7 +307,7 @@ func (m *Migrator) planRollback(inFiles []Migration, inDB []Migration) (actions
 
 	// all prior states to the rollbacks seems OK, performing rollbacks (in reverse)
 	for idx := len(inDB) - 1; idx >= rollbackIdx; idx-- {
-		actions = append(actions, Plan{ActionRollback, inDB[idx]})
+		actions = append(actions, Plan{ActionRollback, withGoFuncs(inDB[idx])})
 	}
 	return
 }
@@ -208,6 +317,21 @@ func (m *Migrator) Apply(ctx context.Context, plan Plan) (err error) {
 		scope data.Scope
 		mig   Migration
 	)
+
+	// no-transaction migrations run first, the scope below then only records the result
+	noTxSQL, noTx := plan.nonTransactional()
+	if noTx {
+		if err = m.execNoTx(ctx, plan.Migration.Name, noTxSQL); err != nil {
+			return
+		}
+		defer func() {
+			if err != nil {
+				err = fmt.Errorf("migrator: %s ran without a transaction but its %s could not be "+
+					"recorded, update the migrations table by hand: %w", plan.Migration.Name, plan.Action, err)
+			}
+		}()
+	}
+
 	if scope, err = data.NewScope(ctx, m.db); err != nil {
 		return
 	} else {
@@ -216,13 +340,16 @@ func (m *Migrator) Apply(ctx context.Context, plan Plan) (err error) {
 
 	if err = scope.Exec(CreateMigrationsTableSQL); err != nil {
 		return
+	} else if err = scope.Exec(UpgradeMigrationsTableSQL); err != nil {
+		return
 	}
 
 	mig = plan.Migration
+	sum := Checksum(mig.UpSQL, mig.DownSQL)
 
 	switch plan.Action {
 	case ActionResync:
-		if err = scope.Exec(UpdateMigrationSQL, mig.Name, mig.UpSQL, mig.DownSQL); err != nil {
+		if err = scope.Exec(UpdateMigrationSQL, mig.Name, mig.UpSQL, mig.DownSQL, sum, mig.Namespace); err != nil {
 			return
 		}
 
@@ -235,16 +362,28 @@ func (m *Migrator) Apply(ctx context.Context, plan Plan) (err error) {
 		}
 
 	case ActionMigrate:
-		if err = scope.Exec(UpdateMigrationSQL, mig.Name, mig.UpSQL, mig.DownSQL); err != nil {
+		if err = scope.Exec(RecordMigrationSQL, mig.Name, mig.UpSQL, mig.DownSQL, sum, appliedBy(), mig.Namespace); err != nil {
 			return
-		} else if err = scope.Exec(plan.Migration.UpSQL); err != nil {
+		} else if !noTx {
+			if err = mig.up(scope); err != nil {
+				return
+			}
+		}
+
+	case ActionBaseline:
+		if err = scope.Exec(RecordMigrationSQL, mig.Name, mig.UpSQL, mig.DownSQL, sum, appliedBy(), mig.Namespace); err != nil {
+			return
+		} else if err = scope.Exec(SupersedeMigrationsSQL, mig.Name, mig.Supersedes()); err != nil {
 			return
 		}
 
 	case ActionRollback:
-		if err = scope.Exec(plan.Migration.DownSQL); err != nil {
-			return
-		} else if err = scope.Exec(PruneMigrationSQL, mig.Name); err != nil {
+		if !noTx {
+			if err = mig.down(scope); err != nil {
+				return
+			}
+		}
+		if err = scope.Exec(PruneMigrationSQL, mig.Name); err != nil {
 			return
 		}
 
@@ -254,3 +393,25 @@ func (m *Migrator) Apply(ctx context.Context, plan Plan) (err error) {
 
 	return
 }
+
+func (m Migration) up(scope data.Scope) error {
+	switch {
+	case !m.IsGo():
+		return scope.Exec(m.UpSQL)
+	case m.Up == nil:
+		return fmt.Errorf("migrator: go migration %s is not registered", m.Name)
+	default:
+		return m.Up(scope.Context())
+	}
+}
+
+func (m Migration) down(scope data.Scope) error {
+	switch {
+	case !m.IsGo():
+		return scope.Exec(m.DownSQL)
+	case m.Down == nil:
+		return fmt.Errorf("migrator: go migration %s is not registered or cannot be rolled back", m.Name)
+	default:
+		return m.Down(scope.Context())
+	}
+}
@@ -0,0 +1,116 @@
+package migrator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// InNamespace returns a Migrator that only plans and applies the migrations of the given
+// namespace, e.g. to roll back one app fragment's migrations without touching others'.
+func (m *Migrator) InNamespace(namespace string) *Migrator {
+	return &Migrator{db: m.db, src: m.src, namespace: &namespace}
+}
+
+// withNamespaces assigns each migration the namespace it was embedded under. Migrations
+// loaded from disk during development are matched to their embedded copy by name.
+func withNamespaces(migrations []Migration) ([]Migration, error) {
+	namespaces := map[string]string{}
+	for _, emb := range embeddedMigrations {
+		part, err := loadMigrationsFS(emb.fsys)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mig := range part {
+			if ns, ok := namespaces[mig.Name]; ok && ns != emb.namespace {
+				return nil, fmt.Errorf("migrator: migration %s is embedded in both %q and %q namespaces",
+					mig.Name, ns, emb.namespace)
+			}
+			namespaces[mig.Name] = emb.namespace
+		}
+	}
+
+	for idx := range migrations {
+		if ns, ok := namespaces[migrations[idx].Name]; ok {
+			migrations[idx].Namespace = ns
+		}
+	}
+	return migrations, nil
+}
+
+// resolveNamespaces takes the namespace of migrations recorded in the database from the
+// source, which is authoritative and also covers rows recorded before namespaces were
+// tracked. Rows replaced by a baseline go along with the baseline.
+func resolveNamespaces(inFiles, inDB []Migration) []Migration {
+	namespaces := map[string]string{}
+	for _, mig := range inFiles {
+		namespaces[mig.Name] = mig.Namespace
+		for _, name := range mig.Supersedes() {
+			namespaces[name] = mig.Namespace
+		}
+	}
+
+	inDB = slices.Clone(inDB)
+	for idx := range inDB {
+		if ns, ok := namespaces[inDB[idx].Name]; ok {
+			inDB[idx].Namespace = ns
+		}
+	}
+	return inDB
+}
+
+func inNamespace(migrations []Migration, namespace string) []Migration {
+	return slices.DeleteFunc(slices.Clone(migrations), func(m Migration) bool {
+		return m.Namespace != namespace
+	})
+}
+
+// planByNamespace plans each namespace on its own, so that fragments with interleaving
+// timestamps don't look out of order to each other. The resulting migrations are then
+// run in timestamp order across namespaces, so a migration can still depend on an older
+// one from another fragment.
+func planByNamespace(inFiles, inDB []Migration, plan func(inFiles, inDB []Migration) ([]Plan, bool, error)) (actions []Plan, dirty bool, err error) {
+	var namespaces []string
+	for _, mig := range slices.Concat(inFiles, inDB) {
+		if !slices.Contains(namespaces, mig.Namespace) {
+			namespaces = append(namespaces, mig.Namespace)
+		}
+	}
+	slices.Sort(namespaces)
+
+	for _, ns := range namespaces {
+		nsActions, nsDirty, err := plan(inNamespace(inFiles, ns), inNamespace(inDB, ns))
+		if err != nil {
+			if ns != "" {
+				err = fmt.Errorf("%s: %w", ns, err)
+			}
+			return nil, false, err
+		}
+
+		actions = append(actions, nsActions...)
+		dirty = dirty || nsDirty
+	}
+
+	// bookkeeping first, then baselines, which replace everything squashed before them,
+	// then migrations in timestamp order
+	slices.SortStableFunc(actions, func(a, b Plan) int {
+		aMig, bMig := a.Action == ActionMigrate, b.Action == ActionMigrate
+		switch {
+		case aMig && bMig && a.Migration.IsBaseline() != b.Migration.IsBaseline():
+			if a.Migration.IsBaseline() {
+				return -1
+			}
+			return 1
+		case aMig && bMig:
+			return strings.Compare(a.Migration.Name, b.Migration.Name)
+		case aMig:
+			return 1
+		case bMig:
+			return -1
+		default:
+			return 0
+		}
+	})
+	return actions, dirty, nil
+}
@@ -0,0 +1,38 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanByNamespace(t *testing.T) {
+	var (
+		m     Migrator
+		root1 = Migration{Name: "202401010000_users", UpSQL: "A"}
+		root2 = Migration{Name: "202403010000_profiles", UpSQL: "B"}
+		files = Migration{Name: "202402010000_files", Namespace: "files", UpSQL: "C"}
+		audit = Migration{Name: "202312010000_audit", Namespace: "audit", UpSQL: "D"}
+	)
+
+	// recorded before namespaces were tracked
+	inDB := resolveNamespaces(
+		[]Migration{root1, files, root2, audit},
+		[]Migration{{Name: root1.Name, UpSQL: "A"}, {Name: files.Name, UpSQL: "C"}},
+	)
+	require.Equal(t, "files", inDB[1].Namespace)
+
+	// audit's migration is older than everything applied, but is simply pending within
+	// its own namespace
+	plans, dirty, err := planByNamespace([]Migration{audit, root1, files, root2}, inDB, m.planMigrate)
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, []Plan{{ActionMigrate, audit}, {ActionMigrate, root2}}, plans)
+	require.Contains(t, plans[0].String(), "audit/audit")
+
+	// fragments roll back on their own
+	onlyFiles := inNamespace(inDB, "files")
+	plans, _, err = m.planRollback(inNamespace([]Migration{root1, files, root2}, "files"), onlyFiles, 1)
+	require.NoError(t, err)
+	require.Equal(t, []Plan{{ActionRollback, inDB[1]}}, plans)
+}
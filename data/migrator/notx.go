@@ -0,0 +1,141 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"fx.prodigy9.co/fxlog"
+)
+
+// NoTransactionMarker, in the leading comments of a migration file, makes the migrator
+// run that file outside of a transaction, one statement at a time. This is required for
+// statements Postgres refuses to run in a transaction such as CREATE INDEX CONCURRENTLY.
+const NoTransactionMarker = "-- fx:no-transaction"
+
+// NoTransaction reports whether sql starts with a comment block containing
+// NoTransactionMarker.
+func NoTransaction(sql string) bool {
+	return hasMarker(sql, NoTransactionMarker)
+}
+
+// nonTransactional returns the SQL that applying the plan runs outside of a transaction,
+// if any.
+func (p Plan) nonTransactional() (string, bool) {
+	mig := p.Migration
+	switch {
+	case mig.IsGo():
+		return "", false
+	case p.Action == ActionMigrate && NoTransaction(mig.UpSQL):
+		return mig.UpSQL, true
+	case p.Action == ActionRollback && NoTransaction(mig.DownSQL):
+		return mig.DownSQL, true
+	default:
+		return "", false
+	}
+}
+
+// execNoTx runs each statement of sql on its own, outside of any transaction. Since
+// statements that already ran cannot be rolled back, a failure is reported with exactly
+// how far it got.
+func (m *Migrator) execNoTx(ctx context.Context, name, sql string) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stmts := splitStatements(sql)
+	for idx, stmt := range stmts {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migrator: %s runs without a transaction and failed at statement "+
+				"%d of %d, the %d statement(s) before it were applied and NOT rolled back, and the "+
+				"migration was not recorded as applied. Repair the database by hand before "+
+				"retrying (e.g. drop INVALID indexes left by CREATE INDEX CONCURRENTLY): %w",
+				name, idx+1, len(stmts), idx, err)
+		}
+
+		fxlog.Log("statement applied without transaction",
+			fxlog.String("migration", name),
+			fxlog.Int("statement", idx+1),
+			fxlog.Int("statements", len(stmts)),
+		)
+	}
+	return nil
+}
+
+// splitStatements splits sql on semicolons that are not inside quotes, dollar-quoted
+// strings or comments. Empty statements are dropped.
+func splitStatements(sql string) (stmts []string) {
+	var (
+		start = 0
+		idx   = 0
+	)
+
+	flush := func(end int) {
+		if stmt := strings.TrimSpace(sql[start:end]); stmt != "" && !onlyComments(stmt) {
+			stmts = append(stmts, stmt)
+		}
+		start = end + 1
+	}
+
+	for idx < len(sql) {
+		switch rest := sql[idx:]; {
+		case strings.HasPrefix(rest, "--"):
+			idx += skipUntil(rest, "\n", 2)
+		case strings.HasPrefix(rest, "/*"):
+			idx += skipUntil(rest, "*/", 2)
+		case rest[0] == '\'' || rest[0] == '"':
+			idx += skipUntil(rest, rest[:1], 1)
+		case rest[0] == '$':
+			if tag := dollarTag(rest); tag != "" {
+				idx += skipUntil(rest, tag, len(tag))
+			} else {
+				idx++
+			}
+		case rest[0] == ';':
+			flush(idx)
+			idx++
+		default:
+			idx++
+		}
+	}
+
+	flush(len(sql))
+	return stmts
+}
+
+// skipUntil returns the length of s up to and including the first end found after
+// offset, or len(s) if there is none.
+func skipUntil(s, end string, offset int) int {
+	if pos := strings.Index(s[offset:], end); pos >= 0 {
+		return offset + pos + len(end)
+	}
+	return len(s)
+}
+
+// dollarTag returns the opening tag of a dollar-quoted string, e.g. `$$` or `$body$`,
+// or "" if s does not start with one.
+func dollarTag(s string) string {
+	for idx := 1; idx < len(s); idx++ {
+		switch c := s[idx]; {
+		case c == '$':
+			return s[:idx+1]
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z',
+			idx > 1 && '0' <= c && c <= '9':
+			continue
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func onlyComments(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
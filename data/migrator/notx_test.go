@@ -0,0 +1,41 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoTransaction(t *testing.T) {
+	require.True(t, NoTransaction("-- fx:no-transaction\nCREATE INDEX CONCURRENTLY i ON t (c);"))
+	require.True(t, NoTransaction("\n-- index for search\n-- fx:no-transaction\nCREATE INDEX CONCURRENTLY i ON t (c);"))
+	require.False(t, NoTransaction("CREATE INDEX i ON t (c);\n-- fx:no-transaction"))
+	require.False(t, NoTransaction("CREATE INDEX i ON t (c);"))
+
+	plan := Plan{ActionMigrate, Migration{Name: "1_index", UpSQL: "-- fx:no-transaction\nSELECT 1", DownSQL: "SELECT 2"}}
+	sql, ok := plan.nonTransactional()
+	require.True(t, ok)
+	require.Equal(t, plan.Migration.UpSQL, sql)
+	require.Contains(t, plan.String(), "(no transaction)")
+
+	plan.Action = ActionRollback
+	_, ok = plan.nonTransactional()
+	require.False(t, ok)
+}
+
+func TestSplitStatements(t *testing.T) {
+	stmts := splitStatements(`-- fx:no-transaction
+CREATE INDEX CONCURRENTLY a ON t (c);
+/* ; */ SELECT 'a;b', "x;y";
+CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;
+DO $$ BEGIN PERFORM 1; END $$
+;;
+-- trailing comment`)
+
+	require.Equal(t, []string{
+		"-- fx:no-transaction\nCREATE INDEX CONCURRENTLY a ON t (c)",
+		`/* ; */ SELECT 'a;b', "x;y"`,
+		"CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql",
+		"DO $$ BEGIN PERFORM 1; END $$",
+	}, stmts)
+}
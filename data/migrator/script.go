@@ -0,0 +1,122 @@
+package migrator
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var placeholderRx = regexp.MustCompile(`\$\d+`)
+
+// WriteScript writes the SQL that applying plans would execute, with transaction
+// boundaries, for review without touching the database. Statement arguments are inlined
+// as literals and Go migrations are marked with a comment since they have no SQL.
+func WriteScript(w io.Writer, plans []Plan) error {
+	for _, plan := range plans {
+		if _, err := io.WriteString(w, plan.Script()+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Script returns the SQL that Apply executes for the plan, see WriteScript.
+func (p Plan) Script() string {
+	var (
+		sb  = &strings.Builder{}
+		mig = p.Migration
+		sum = Checksum(mig.UpSQL, mig.DownSQL)
+	)
+
+	stmt := func(sql string, args ...any) {
+		sb.WriteString(inlineArgs(dedent(sql), args) + "\n")
+	}
+	body := func(sql string) {
+		if mig.IsGo() {
+			sb.WriteString("-- (go migration, runs Go code inside this transaction)\n")
+			return
+		}
+		if sql = strings.TrimSpace(sql); !strings.HasSuffix(sql, ";") {
+			sql += ";"
+		}
+		sb.WriteString(sql + "\n")
+	}
+
+	sb.WriteString("-- " + strings.TrimSpace(p.String()) + "\n")
+
+	noTxSQL, noTx := p.nonTransactional()
+	if noTx {
+		sb.WriteString("-- (no transaction, each statement runs on its own)\n")
+		for _, s := range splitStatements(noTxSQL) {
+			sb.WriteString(s + ";\n")
+		}
+	}
+
+	sb.WriteString("BEGIN;\n")
+	stmt(CreateMigrationsTableSQL)
+	stmt(UpgradeMigrationsTableSQL)
+
+	switch p.Action {
+	case ActionResync:
+		stmt(UpdateMigrationSQL+";", mig.Name, mig.UpSQL, mig.DownSQL, sum, mig.Namespace)
+	case ActionIgnore:
+		// no-op
+	case ActionPrune:
+		stmt(PruneMigrationSQL+";", mig.Name)
+	case ActionMigrate:
+		stmt(RecordMigrationSQL+";", mig.Name, mig.UpSQL, mig.DownSQL, sum, appliedBy(), mig.Namespace)
+		if !noTx {
+			body(mig.UpSQL)
+		}
+	case ActionBaseline:
+		stmt(RecordMigrationSQL+";", mig.Name, mig.UpSQL, mig.DownSQL, sum, appliedBy(), mig.Namespace)
+		stmt(SupersedeMigrationsSQL+";", mig.Name, "{"+strings.Join(mig.Supersedes(), ",")+"}")
+	case ActionRollback:
+		if !noTx {
+			body(mig.DownSQL)
+		}
+		stmt(PruneMigrationSQL+";", mig.Name)
+	}
+
+	sb.WriteString("COMMIT;\n")
+	return sb.String()
+}
+
+// inlineArgs replaces $n placeholders with args quoted as SQL string literals, in a
+// single pass so that placeholders inside the inlined values are left alone.
+func inlineArgs(sql string, args []any) string {
+	return placeholderRx.ReplaceAllStringFunc(sql, func(placeholder string) string {
+		idx, err := strconv.Atoi(placeholder[1:])
+		if err != nil || idx < 1 || idx > len(args) {
+			return placeholder
+		}
+		return "'" + strings.ReplaceAll(fmt.Sprint(args[idx-1]), "'", "''") + "'"
+	})
+}
+
+// dedent removes the indentation shared by all non-blank lines of the SQL constants.
+func dedent(sql string) string {
+	lines := strings.Split(strings.Trim(sql, "\n"), "\n")
+
+	prefix, found := "", false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			prefix, found = indent, true
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	for idx, line := range lines {
+		lines[idx] = strings.TrimPrefix(line, prefix)
+	}
+	return strings.Join(lines, "\n")
+}
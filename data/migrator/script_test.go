@@ -0,0 +1,29 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanScript(t *testing.T) {
+	mig := Migration{
+		Name:    "202401010000_create_todos",
+		UpSQL:   "CREATE TABLE todos (title text DEFAULT 'it''s $1');",
+		DownSQL: "DROP TABLE todos",
+	}
+
+	script := Plan{ActionMigrate, mig}.Script()
+	require.True(t, strings.HasPrefix(script, "-- migrate => 202401010000 create_todos\nBEGIN;\nCREATE TABLE IF NOT EXISTS migrations\n(\n\tname"))
+	require.Contains(t, script, "VALUES ('202401010000_create_todos', 'CREATE TABLE todos (title text DEFAULT ''it''''s $1'');', 'DROP TABLE todos', '")
+	require.True(t, strings.HasSuffix(script, "\nCREATE TABLE todos (title text DEFAULT 'it''s $1');\nCOMMIT;\n"))
+
+	script = Plan{ActionRollback, mig}.Script()
+	require.Contains(t, script, "\nDROP TABLE todos;\nDELETE FROM migrations\nWHERE name = '202401010000_create_todos';\nCOMMIT;\n")
+
+	mig.UpSQL = "-- fx:no-transaction\nCREATE INDEX CONCURRENTLY a ON t (c);\nCREATE INDEX CONCURRENTLY b ON t (d);"
+	script = Plan{ActionMigrate, mig}.Script()
+	require.Contains(t, script, "CREATE INDEX CONCURRENTLY b ON t (d);\nBEGIN;\n")
+	require.True(t, strings.HasSuffix(script, "namespace = '';\nCOMMIT;\n"))
+}
@@ -20,9 +20,14 @@ var (
 	MigrationPathConfig = config.StrDef("DATABASE_MIGRATIONS", ".")
 	ErrNoMigrations     = errors.New("migrator: no migrations found")
 
-	embeddedMigrations []fs.FS
+	embeddedMigrations []embedded
 )
 
+type embedded struct {
+	namespace string
+	fsys      fs.FS
+}
+
 func IsNoMigrations(err error) bool {
 	return errors.Is(err, ErrNoMigrations)
 }
@@ -91,7 +96,15 @@ func FromDB(ctx context.Context, db *sqlx.DB) Source {
 // register their own embedded migrations independently — the root app composes the
 // union at Start.
 func Embed(fsys embed.FS) {
-	embeddedMigrations = append(embeddedMigrations, fsys)
+	EmbedNamespace("", fsys)
+}
+
+// EmbedNamespace is Embed for an app fragment's migrations, which are then planned
+// separately from other namespaces' and can be rolled back on their own, see
+// Migrator.InNamespace. The root app's migrations use the "" namespace. app.Start
+// registers each mounted fragment under its name.
+func EmbedNamespace(namespace string, fsys embed.FS) {
+	embeddedMigrations = append(embeddedMigrations, embedded{namespace, fsys})
 }
 
 // LoadAuto checks a few different hard-coded sources automagically for migrations in the
@@ -107,7 +120,25 @@ func Embed(fsys embed.FS) {
 //
 // In case a custom workflow is required, set DATABASE_MIGRATIONS env var configuration
 // to the desired path since it will always take precedence over everything else.
+//
+// Go migrations added with Register are merged in regardless of where the SQL migrations
+// were found. If there is a baseline migration (see `data squash-migrations`), the
+// migrations it replaces are left out, so empty databases start from the baseline.
 func LoadAuto(cfg *config.Source) ([]Migration, error) {
+	migrations, err := loadAutoSQL(cfg)
+	if err != nil && !(IsNoMigrations(err) && len(goMigrations) > 0) {
+		return nil, err
+	}
+
+	if migrations, err = withNamespaces(migrations); err != nil {
+		return nil, err
+	} else if migrations, err = withGoMigrations(migrations); err != nil {
+		return nil, err
+	}
+	return fromBaseline(migrations), nil
+}
+
+func loadAutoSQL(cfg *config.Source) ([]Migration, error) {
 	migPath, ok := config.GetOK(cfg, MigrationPathConfig)
 	if ok {
 		// if the env var is set, but there are no migrations, we let it errors because it's
@@ -135,8 +166,8 @@ func LoadAuto(cfg *config.Source) ([]Migration, error) {
 	}
 
 	var all []Migration
-	for _, fsys := range embeddedMigrations {
-		part, err := Load(FromFS(fsys))
+	for _, emb := range embeddedMigrations {
+		part, err := Load(FromFS(emb.fsys))
 		if err != nil && !IsNoMigrations(err) {
 			return nil, err
 		}
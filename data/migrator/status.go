@@ -0,0 +1,152 @@
+package migrator
+
+import (
+	"context"
+	"strings"
+
+	"github.com/chakrit/gendiff"
+)
+
+type State int
+
+const (
+	// StateApplied is a migration that has been run and is unchanged since.
+	StateApplied = State(iota)
+	// StatePending is a migration that has yet to be run.
+	StatePending
+	// StateModified is a migration that has been run but whose SQL has changed since.
+	StateModified
+	// StateMissing is a migration that has been run but no longer exists in the source.
+	StateMissing
+	// StateSuperseded is a migration that has been replaced by a baseline.
+	StateSuperseded
+)
+
+func (s State) String() string {
+	switch s {
+	case StateApplied:
+		return "applied"
+	case StatePending:
+		return "pending"
+	case StateModified:
+		return "modified"
+	case StateMissing:
+		return "missing"
+	case StateSuperseded:
+		return "superseded"
+	default:
+		return "(unknown)"
+	}
+}
+
+// Status describes a single migration as found in the source and in the database.
+// Either side is nil when the migration only exists on the other one.
+type Status struct {
+	State   State
+	Name    string
+	Source  *Migration
+	Applied *Migration
+}
+
+// Status compares the migrations in the source against the ones recorded in the database.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	inFiles, err := Load(m.src)
+	if err != nil {
+		return nil, err
+	}
+
+	inDB, err := Load(FromDB(ctx, m.db))
+	if err != nil && !IsNoMigrations(err) {
+		return nil, err
+	}
+	return statuses(inFiles, inDB), nil
+}
+
+func statuses(inFiles []Migration, inDB []Migration) (result []Status) {
+	for _, d := range gendiff.Make(migrationDiff{inDB, inFiles}) {
+		switch d.Op {
+		case gendiff.Insert:
+			for ridx := d.Rstart; ridx < d.Rend; ridx++ {
+				result = append(result, Status{StatePending, inFiles[ridx].Name, &inFiles[ridx], nil})
+			}
+
+		case gendiff.Delete:
+			for lidx := d.Lstart; lidx < d.Lend; lidx++ {
+				state := StateMissing
+				if inDB[lidx].SupersededBy != "" {
+					state = StateSuperseded
+				}
+				result = append(result, Status{state, inDB[lidx].Name, nil, &inDB[lidx]})
+			}
+
+		case gendiff.Match:
+			lidx, ridx := d.Lstart, d.Rstart
+			for lidx < d.Lend && ridx < d.Rend {
+				mDB, mFile := &inDB[lidx], &inFiles[ridx]
+				state := StateApplied
+				if !unchanged(*mDB, *mFile) {
+					state = StateModified
+				}
+				result = append(result, Status{state, mFile.Name, mFile, mDB})
+				lidx += 1
+				ridx += 1
+			}
+		}
+	}
+	return
+}
+
+// Diff returns a line diff from the applied SQL to the SQL in the source, for modified
+// migrations. It is empty otherwise.
+func (s Status) Diff() string {
+	if s.State != StateModified {
+		return ""
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString("--- " + s.Name + " (database)\n")
+	sb.WriteString("+++ " + s.Name + " (source)\n")
+	writeLineDiff(sb, "up", s.Applied.UpSQL, s.Source.UpSQL)
+	writeLineDiff(sb, "down", s.Applied.DownSQL, s.Source.DownSQL)
+	return sb.String()
+}
+
+type lineDiff struct {
+	left  []string
+	right []string
+}
+
+var _ gendiff.Interface = lineDiff{}
+
+func (d lineDiff) LeftLen() int        { return len(d.left) }
+func (d lineDiff) RightLen() int       { return len(d.right) }
+func (d lineDiff) Equal(l, r int) bool { return d.left[l] == d.right[r] }
+
+func writeLineDiff(sb *strings.Builder, label, before, after string) {
+	if normalizeSQL(before) == normalizeSQL(after) {
+		return
+	}
+
+	diff := lineDiff{
+		left:  strings.Split(strings.TrimSpace(before), "\n"),
+		right: strings.Split(strings.TrimSpace(after), "\n"),
+	}
+
+	sb.WriteString("@@ " + label + " @@\n")
+	for _, d := range gendiff.Make(diff) {
+		switch d.Op {
+		case gendiff.Match:
+			for idx := d.Lstart; idx < d.Lend; idx++ {
+				sb.WriteString(" " + diff.left[idx] + "\n")
+			}
+		case gendiff.Delete:
+			for idx := d.Lstart; idx < d.Lend; idx++ {
+				sb.WriteString("-" + diff.left[idx] + "\n")
+			}
+		case gendiff.Insert:
+			for idx := d.Rstart; idx < d.Rend; idx++ {
+				sb.WriteString("+" + diff.right[idx] + "\n")
+			}
+		}
+	}
+}
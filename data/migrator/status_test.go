@@ -0,0 +1,81 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksum(t *testing.T) {
+	sum := Checksum("CREATE TABLE todos (id int);", "DROP TABLE todos;")
+	require.Equal(t, sum, Checksum("CREATE TABLE\n\ttodos (id int);\n", "  DROP TABLE todos;"))
+	require.NotEqual(t, sum, Checksum("CREATE TABLE todos (id bigint);", "DROP TABLE todos;"))
+	require.NotEqual(t, sum, Checksum("DROP TABLE todos;", "CREATE TABLE todos (id int);"))
+
+	stored := Migration{UpSQL: "SELECT 1", Checksum: "abc"}
+	require.Equal(t, "abc", stored.Sum())
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	require.Equal(t,
+		`INSERT INTO t (a, "b  c") VALUES ('a  b', 'it''s  ok')`,
+		normalizeSQL("  INSERT INTO t (a,\n\t\"b  c\")\n  VALUES ('a  b',   'it''s  ok')\n"))
+
+	// quotes in comments don't start strings, function bodies are code
+	require.Equal(t,
+		"/* it's */ CREATE FUNCTION f() RETURNS text AS $$ BEGIN RETURN 'x  y'; END; $$ LANGUAGE plpgsql;",
+		normalizeSQL("/* it's */\nCREATE FUNCTION f() RETURNS text AS $$\nBEGIN\n    RETURN 'x  y';\nEND;\n$$ LANGUAGE plpgsql;"))
+
+	require.NotEqual(t, Checksum("SELECT 'a  b'", ""), Checksum("SELECT 'a b'", ""))
+	require.Equal(t, Checksum("SELECT  'a  b'", ""), Checksum("SELECT\n'a  b'", ""))
+}
+
+func TestUnchanged_LegacyChecksum(t *testing.T) {
+	var (
+		upSQL = "INSERT INTO settings VALUES ('greeting', 'hello  world');"
+		file  = Migration{Name: "1_settings", UpSQL: upSQL}
+	)
+
+	// rows recorded with the legacy checksum still match their file...
+	legacy := Migration{Name: "1_settings", UpSQL: upSQL, Checksum: legacyChecksum(upSQL, "")}
+	require.NotEqual(t, legacy.Sum(), file.Sum())
+	require.True(t, unchanged(legacy, file))
+
+	// ...but not once whitespace inside a string changes
+	edited := Migration{Name: "1_settings", UpSQL: "INSERT INTO settings VALUES ('greeting', 'hello world');"}
+	require.Equal(t, legacy.Checksum, legacyChecksum(edited.UpSQL, ""))
+	require.False(t, unchanged(legacy, edited))
+
+	current := Migration{Name: "1_settings", UpSQL: upSQL, Checksum: Checksum(upSQL, "")}
+	require.True(t, unchanged(current, file))
+	require.False(t, unchanged(current, edited))
+}
+
+func TestStatuses(t *testing.T) {
+	inDB := []Migration{
+		{Name: "1_applied", UpSQL: "SELECT 1", DownSQL: "SELECT 1"},
+		{Name: "2_missing", UpSQL: "SELECT 2", DownSQL: "SELECT 2"},
+		{Name: "3_modified", UpSQL: "SELECT 3", DownSQL: "SELECT 3"},
+	}
+	inFiles := []Migration{
+		{Name: "1_applied", UpSQL: "SELECT\n  1", DownSQL: "SELECT 1"},
+		{Name: "3_modified", UpSQL: "SELECT 33", DownSQL: "SELECT 3"},
+		{Name: "4_pending", UpSQL: "SELECT 4", DownSQL: "SELECT 4"},
+	}
+
+	result := statuses(inFiles, inDB)
+
+	var states []State
+	for _, status := range result {
+		states = append(states, status.State)
+	}
+	require.Equal(t, []State{StateApplied, StateMissing, StateModified, StatePending}, states)
+	require.Empty(t, result[0].Diff())
+	require.Equal(t, ""+
+		"--- 3_modified (database)\n"+
+		"+++ 3_modified (source)\n"+
+		"@@ up @@\n"+
+		"-SELECT 3\n"+
+		"+SELECT 33\n",
+		result[2].Diff())
+}
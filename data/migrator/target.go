@@ -0,0 +1,90 @@
+package migrator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// matchTarget reports whether name is the target migration, either by full name or by
+// its timestamp prefix.
+func matchTarget(name, target string) bool {
+	return name == target || strings.HasPrefix(name, target+"_")
+}
+
+// parseCount parses a target given as a number of migrations.
+func parseCount(target string) (int, bool, error) {
+	count, err := strconv.Atoi(target)
+	if err != nil {
+		return 0, false, nil
+	} else if count < 0 {
+		return 0, true, fmt.Errorf("migrator: invalid target count %d", count)
+	}
+
+	// 12-digit timestamps are names, not counts
+	return count, len(target) < 12, nil
+}
+
+// migrateTo trims the migrations planned by planMigrate to stop at target. Other actions
+// (prunes, resyncs) are kept as they are.
+func migrateTo(actions []Plan, target string) ([]Plan, error) {
+	if target == "" {
+		return nil, fmt.Errorf("migrator: a target is required")
+	}
+
+	var pending []int
+	for idx, action := range actions {
+		if action.Action == ActionMigrate {
+			pending = append(pending, idx)
+		}
+	}
+
+	keep := 0
+	if count, isCount, err := parseCount(target); err != nil {
+		return nil, err
+	} else if isCount {
+		if count > len(pending) {
+			return nil, fmt.Errorf("migrator: cannot apply %d migrations, only %d pending", count, len(pending))
+		}
+		keep = count
+	} else {
+		pos := slices.IndexFunc(pending, func(idx int) bool {
+			return matchTarget(actions[idx].Migration.Name, target)
+		})
+		if pos < 0 {
+			return nil, fmt.Errorf("migrator: %s is not a pending migration", target)
+		}
+		keep = pos + 1
+	}
+
+	var result []Plan
+	for idx, action := range actions {
+		if action.Action != ActionMigrate || slices.Index(pending, idx) < keep {
+			result = append(result, action)
+		}
+	}
+	return result, nil
+}
+
+// rollbackCount resolves target into the number of applied migrations to roll back.
+func rollbackCount(inDB []Migration, target string) (int, error) {
+	if target == "" {
+		return 0, fmt.Errorf("migrator: a target is required")
+	}
+
+	if count, isCount, err := parseCount(target); err != nil {
+		return 0, err
+	} else if isCount {
+		if count > len(inDB) {
+			return 0, fmt.Errorf("migrator: cannot roll back %d migrations, only %d applied", count, len(inDB))
+		}
+		return count, nil
+	}
+
+	idx := slices.IndexFunc(inDB, func(m Migration) bool { return matchTarget(m.Name, target) })
+	if idx < 0 {
+		return 0, fmt.Errorf("migrator: %s is not an applied migration", target)
+	}
+	return len(inDB) - 1 - idx, nil
+}
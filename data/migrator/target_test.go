@@ -0,0 +1,72 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateTo(t *testing.T) {
+	actions := []Plan{
+		{ActionPrune, Migration{Name: "202401010000_removed"}},
+		{ActionMigrate, Migration{Name: "202402010000_first"}},
+		{ActionMigrate, Migration{Name: "202403010000_second"}},
+		{ActionMigrate, Migration{Name: "202404010000_third"}},
+	}
+
+	names := func(plans []Plan) (result []string) {
+		for _, plan := range plans {
+			result = append(result, plan.Migration.Name)
+		}
+		return
+	}
+
+	result, err := migrateTo(actions, "2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"202401010000_removed", "202402010000_first", "202403010000_second"}, names(result))
+
+	result, err = migrateTo(actions, "202402010000")
+	require.NoError(t, err)
+	require.Equal(t, []string{"202401010000_removed", "202402010000_first"}, names(result))
+
+	result, err = migrateTo(actions, "202404010000_third")
+	require.NoError(t, err)
+	require.Len(t, result, 4)
+
+	_, err = migrateTo(actions, "4")
+	require.Error(t, err)
+	_, err = migrateTo(actions, "202401010000_removed")
+	require.Error(t, err)
+	_, err = migrateTo(actions, "")
+	require.Error(t, err)
+}
+
+func TestRollbackCount(t *testing.T) {
+	inDB := []Migration{
+		{Name: "202401010000_first"},
+		{Name: "202402010000_second"},
+		{Name: "202403010000_third"},
+	}
+
+	count, err := rollbackCount(inDB, "2")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	count, err = rollbackCount(inDB, "202401010000")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	count, err = rollbackCount(inDB, "202403010000_third")
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	_, err = rollbackCount(inDB, "4")
+	require.Error(t, err)
+	_, err = rollbackCount(inDB, "202405010000")
+	require.Error(t, err)
+
+	var m Migrator
+	plans, _, err := m.planRollback(inDB, inDB, 2)
+	require.NoError(t, err)
+	require.Equal(t, []Plan{{ActionRollback, inDB[2]}, {ActionRollback, inDB[1]}}, plans)
+}
@@ -18,6 +18,9 @@ const (
 	ActionMigrate
 	// ActionRollback rollbacks the most recent migration
 	ActionRollback
+	// ActionBaseline records a baseline without running it, marking the migrations it
+	// replaces as superseded
+	ActionBaseline
 )
 
 func (act Action) String() string {
@@ -32,6 +35,8 @@ func (act Action) String() string {
 		return "migrate"
 	case ActionRollback:
 		return "rollback"
+	case ActionBaseline:
+		return "baseline"
 	default:
 		return "(unknown)"
 	}
@@ -43,6 +48,9 @@ const (
 	IntentResync = Intent(iota)
 	IntentMigrate
 	IntentRollback
+	// IntentMigrateTo and IntentRollbackTo require a target, see Migrator.PlanTo.
+	IntentMigrateTo
+	IntentRollbackTo
 )
 
 type Plan struct {
@@ -62,5 +70,11 @@ func (p Plan) String() string {
 		date, name = parts[0], parts[1]
 	}
 
+	if p.Migration.Namespace != "" {
+		name = p.Migration.Namespace + "/" + name
+	}
+	if _, noTx := p.nonTransactional(); noTx {
+		name += " (no transaction)"
+	}
 	return fmt.Sprintf("%20s => %s %s", p.Action, date, name)
 }
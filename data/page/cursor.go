@@ -0,0 +1,328 @@
+package page
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fx.prodigy9.co/data"
+	"fx.prodigy9.co/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+var (
+	ErrNoKeys     = errors.New("page: at least one key column is required")
+	ErrInvalidKey = errors.New("page: invalid key column")
+
+	identRx = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	mapper  = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+)
+
+// Key is a column that a cursor-paginated query is ordered by. The set of keys passed to
+// SelectCursor must uniquely identify a row, usually by ending with the primary key.
+type Key struct {
+	Column string
+	Desc   bool
+}
+
+func Asc(column string) Key  { return Key{Column: column} }
+func Desc(column string) Key { return Key{Column: column, Desc: true} }
+
+// CursorMeta is the cursor-based counterpart of Meta. After and Before are opaque
+// cursors obtained from a previous CursorPage's Next and Prev respectively, at most one
+// of them should be set.
+type CursorMeta struct {
+	After  string `json:"after,omitempty"`
+	Before string `json:"before,omitempty"`
+	Limit  int    `json:"limit"`
+
+	// Total requests an additional COUNT(*) over the source query.
+	Total bool `json:"-"`
+}
+
+// CursorPage is a page of results obtained through keyset pagination. Unlike Page, the
+// results do not shift when rows are inserted concurrently and fetching a page does not
+// get slower the further along the client goes.
+type CursorPage[T any] struct {
+	CursorMeta
+	Data []T `json:"data"`
+
+	Next      string `json:"next,omitempty"`
+	Prev      string `json:"prev,omitempty"`
+	TotalRows *int   `json:"total_rows,omitempty"`
+}
+
+func CursorFromRequest(req *http.Request) CursorMeta {
+	return CursorFromQuery(req.URL.Query())
+}
+
+func CursorFromQuery(query url.Values) CursorMeta {
+	meta := CursorMeta{
+		After:  strings.TrimSpace(query.Get("after")),
+		Before: strings.TrimSpace(query.Get("before")),
+		Limit:  DefaultPageSize,
+	}
+
+	if limit, err := strconv.Atoi(strings.TrimSpace(query.Get("limit"))); err == nil && limit > 0 {
+		meta.Limit = limit
+	}
+	if total, err := strconv.ParseBool(strings.TrimSpace(query.Get("total"))); err == nil {
+		meta.Total = total
+	}
+	return meta
+}
+
+// Links returns URLs for the next and previous pages derived from the given URL, which
+// is usually the current request's URL. Either is empty if there is no such page.
+func (p CursorPage[T]) Links(u *url.URL) (next, prev string) {
+	link := func(param, cursor string) string {
+		if cursor == "" {
+			return ""
+		}
+
+		query := u.Query()
+		query.Del("after")
+		query.Del("before")
+		query.Set(param, cursor)
+
+		linkURL := *u
+		linkURL.RawQuery = query.Encode()
+		return linkURL.String()
+	}
+
+	return link("after", p.Next), link("before", p.Prev)
+}
+
+// SelectCursor runs the sql wrapped in a keyset-paginated query ordered by the given keys.
+// The source sql should not have its own ORDER BY or LIMIT clauses and must return the key
+// columns, which are read back from T's `db` tags to build the cursors.
+func SelectCursor[T any](ctx context.Context, out *CursorPage[T], meta CursorMeta, keys []Key, sql string, args ...any) (err error) {
+	if out == nil {
+		return ErrNilOut
+	}
+	if len(keys) == 0 {
+		return ErrNoKeys
+	}
+	for _, key := range keys {
+		if !identRx.MatchString(key.Column) {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key.Column)
+		}
+	}
+	if meta.Limit <= 0 {
+		meta.Limit = DefaultPageSize
+	}
+
+	var (
+		backward = meta.Before != ""
+		param    = "after"
+		cursor   = meta.After
+	)
+	if backward {
+		param, cursor = "before", meta.Before
+	}
+
+	var values []string
+	if cursor != "" {
+		if values, err = decodeCursor(cursor); err != nil || len(values) != len(keys) {
+			return validate.NewFieldError(param, "invalid cursor", cursor)
+		}
+	}
+
+	scope, cancel, err := data.NewScopeErr(ctx, &err)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	var (
+		prefix   = "WITH source AS (" + sql + ")\n"
+		dataArgs = slices.Clone(args)
+		dataSQL  = prefix + "SELECT * FROM source"
+	)
+	if len(values) > 0 {
+		var where string
+		where, dataArgs = keysetCondition(keys, values, backward, dataArgs)
+		dataSQL += "\nWHERE " + where
+	}
+	dataSQL += "\nORDER BY " + keysetOrder(keys, backward) +
+		"\nLIMIT $" + strconv.Itoa(len(dataArgs)+1)
+	dataArgs = append(dataArgs, meta.Limit+1)
+
+	var rows []T
+	if err := scope.Select(&rows, dataSQL, dataArgs...); err != nil {
+		return err
+	}
+
+	more := len(rows) > meta.Limit
+	if more {
+		rows = rows[:meta.Limit]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+	if rows == nil {
+		rows = []T{}
+	}
+
+	result := CursorPage[T]{CursorMeta: meta, Data: rows}
+	if len(rows) > 0 {
+		// going forward, there is a previous page if we came from a cursor and a next page
+		// if there are more rows. backward is the mirror of that.
+		hasNext, hasPrev := more, cursor != ""
+		if backward {
+			hasNext, hasPrev = cursor != "", more
+		}
+
+		if hasNext {
+			if result.Next, err = encodeCursor(rows[len(rows)-1], keys); err != nil {
+				return err
+			}
+		}
+		if hasPrev {
+			if result.Prev, err = encodeCursor(rows[0], keys); err != nil {
+				return err
+			}
+		}
+	}
+
+	if meta.Total {
+		count := 0
+		if err := scope.Get(&count, prefix+"SELECT COUNT(*) FROM source", args...); err != nil {
+			return err
+		}
+		result.TotalRows = &count
+	}
+
+	*out = result
+	return nil
+}
+
+func SelectCursorSQL[T any](ctx context.Context, out *CursorPage[T], meta CursorMeta, keys []Key, sqlgen data.SQLGenerator) (err error) {
+	sql, args := sqlgen.Sql()
+	return SelectCursor(ctx, out, meta, keys, sql, args...)
+}
+
+// keysetCondition builds the expanded form of a row comparison so each key may have its
+// own direction, e.g. for (a DESC, b ASC) after a cursor:
+//
+//	(a < $1) OR (a = $1 AND b > $2)
+func keysetCondition(keys []Key, values []string, backward bool, args []any) (string, []any) {
+	var (
+		argIdxs = make([]string, len(keys))
+		ors     = make([]string, len(keys))
+	)
+	for idx, value := range values {
+		args = append(args, value)
+		argIdxs[idx] = "$" + strconv.Itoa(len(args))
+	}
+
+	for idx, key := range keys {
+		var ands []string
+		for prev := 0; prev < idx; prev++ {
+			ands = append(ands, keys[prev].Column+" = "+argIdxs[prev])
+		}
+
+		op := ">"
+		if key.Desc != backward {
+			op = "<"
+		}
+		ands = append(ands, key.Column+" "+op+" "+argIdxs[idx])
+		ors[idx] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+
+	return strings.Join(ors, " OR "), args
+}
+
+func keysetOrder(keys []Key, backward bool) string {
+	orders := make([]string, len(keys))
+	for idx, key := range keys {
+		if key.Desc != backward {
+			orders[idx] = key.Column + " DESC"
+		} else {
+			orders[idx] = key.Column + " ASC"
+		}
+	}
+	return strings.Join(orders, ", ")
+}
+
+// encodeCursor reads the key columns off the row and encodes them as text so they can be
+// bound back as query parameters regardless of the column types.
+func encodeCursor(row any, keys []Key) (string, error) {
+	val := reflect.Indirect(reflect.ValueOf(row))
+	if val.Kind() != reflect.Struct {
+		return "", fmt.Errorf("page: cursor rows must be structs, got %s", val.Type())
+	}
+
+	var (
+		fields = mapper.TypeMap(val.Type())
+		values = make([]string, len(keys))
+	)
+	for idx, key := range keys {
+		info, ok := fields.Names[key.Column]
+		if !ok {
+			return "", fmt.Errorf("%w: %q not found in %s", ErrInvalidKey, key.Column, val.Type())
+		}
+
+		field := reflectx.FieldByIndexesReadOnly(val, info.Index)
+		str, err := cursorValue(field.Interface())
+		if err != nil {
+			return "", fmt.Errorf("page: key %q: %w", key.Column, err)
+		}
+		values[idx] = str
+	}
+
+	if raw, err := json.Marshal(values); err != nil {
+		return "", err
+	} else {
+		return base64.RawURLEncoding.EncodeToString(raw), nil
+	}
+}
+
+func decodeCursor(cursor string) ([]string, error) {
+	var values []string
+	if raw, err := base64.RawURLEncoding.DecodeString(cursor); err != nil {
+		return nil, err
+	} else if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	} else {
+		return values, nil
+	}
+}
+
+func cursorValue(value any) (string, error) {
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "", errors.New("key value is NULL")
+		}
+		value = rv.Elem().Interface()
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		var err error
+		if value, err = valuer.Value(); err != nil {
+			return "", err
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", errors.New("key value is NULL")
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case []byte:
+		return string(v), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,86 @@
+package page
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type cursorRow struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	Title     *string   `db:"title"`
+}
+
+func TestKeysetCondition(t *testing.T) {
+	keys := []Key{Desc("created_at"), Asc("id")}
+
+	where, args := keysetCondition(keys, []string{"t", "1"}, false, []any{"x"})
+	require.Equal(t, "(created_at < $2) OR (created_at = $2 AND id > $3)", where)
+	require.Equal(t, []any{"x", "t", "1"}, args)
+
+	where, _ = keysetCondition(keys, []string{"t", "1"}, true, nil)
+	require.Equal(t, "(created_at > $1) OR (created_at = $1 AND id < $2)", where)
+}
+
+func TestKeysetOrder(t *testing.T) {
+	keys := []Key{Desc("created_at"), Asc("id")}
+	require.Equal(t, "created_at DESC, id ASC", keysetOrder(keys, false))
+	require.Equal(t, "created_at ASC, id DESC", keysetOrder(keys, true))
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	title := "hello"
+	row := cursorRow{
+		ID:        42,
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Title:     &title,
+	}
+
+	cursor, err := encodeCursor(row, []Key{Desc("created_at"), Desc("id"), Asc("title")})
+	require.NoError(t, err)
+
+	values, err := decodeCursor(cursor)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024-01-02T03:04:05.000000006Z", "42", "hello"}, values)
+
+	_, err = encodeCursor(row, []Key{Asc("missing")})
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	row.Title = nil
+	_, err = encodeCursor(row, []Key{Asc("title")})
+	require.Error(t, err)
+
+	_, err = decodeCursor("not a cursor")
+	require.Error(t, err)
+}
+
+func TestCursorFromQuery(t *testing.T) {
+	meta := CursorFromQuery(url.Values{})
+	require.Equal(t, CursorMeta{Limit: DefaultPageSize}, meta)
+
+	meta = CursorFromQuery(url.Values{
+		"after": {" abc "},
+		"limit": {"5"},
+		"total": {"true"},
+	})
+	require.Equal(t, CursorMeta{After: "abc", Limit: 5, Total: true}, meta)
+
+	meta = CursorFromQuery(url.Values{"limit": {"-1"}})
+	require.Equal(t, DefaultPageSize, meta.Limit)
+}
+
+func TestCursorPage_Links(t *testing.T) {
+	u, err := url.Parse("https://example.com/todos?after=old&limit=5")
+	require.NoError(t, err)
+
+	next, prev := CursorPage[cursorRow]{Next: "n", Prev: "p"}.Links(u)
+	require.Equal(t, "https://example.com/todos?after=n&limit=5", next)
+	require.Equal(t, "https://example.com/todos?before=p&limit=5", prev)
+
+	next, prev = CursorPage[cursorRow]{}.Links(u)
+	require.Empty(t, next)
+	require.Empty(t, prev)
+}
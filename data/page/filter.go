@@ -0,0 +1,229 @@
+package page
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fx.prodigy9.co/validate"
+)
+
+// FieldType is the type of a sortable/filterable field, used to parse filter values from
+// the query string before they're bound as query arguments.
+type FieldType int
+
+const (
+	String = FieldType(iota)
+	Int
+	Float
+	Bool
+	Time
+)
+
+// Fields whitelists which columns of the source query may be used in Meta's Sort and
+// Filters. Anything not listed is rejected with a validation error.
+type Fields map[string]FieldType
+
+// Filter is a single filter[...] entry from the query string, e.g.
+// `filter[status]=done` or `filter[created_at][gte]=2024-01-01`. Op defaults to "eq".
+//
+// Supported ops are eq, ne, lt, lte, gt, gte, in (comma-separated values) and contains
+// (case-insensitive substring, strings only).
+type Filter struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+var filterOps = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"lt":  "<",
+	"lte": "<=",
+	"gt":  ">",
+	"gte": ">=",
+}
+
+func parseFilters(query url.Values) (filters []Filter) {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if strings.HasPrefix(key, "filter[") && strings.HasSuffix(key, "]") {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys) // stable order for generated SQL
+
+	for _, key := range keys {
+		// filter[field] or filter[field][op]
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]"), "][")
+		field, op := strings.TrimSpace(parts[0]), "eq"
+		if len(parts) == 2 {
+			op = strings.ToLower(strings.TrimSpace(parts[1]))
+		} else if len(parts) > 2 {
+			continue
+		}
+
+		for _, value := range query[key] {
+			filters = append(filters, Filter{Field: field, Op: op, Value: strings.TrimSpace(value)})
+		}
+	}
+	return filters
+}
+
+// where translates the filters into a WHERE condition with arguments numbered after the
+// given args.
+func (m Meta) where(args []any) (string, []any, error) {
+	if m.Fields == nil {
+		return "", args, nil
+	}
+
+	var (
+		errs  []error
+		conds []string
+	)
+
+	for _, filter := range m.Filters {
+		var (
+			name     = "filter[" + filter.Field + "]"
+			typ, ok  = m.Fields[filter.Field]
+			argIdx   = "$" + strconv.Itoa(len(args)+1)
+			cond     string
+			arg      any
+			parseErr error
+		)
+		if !ok || !identRx.MatchString(filter.Field) {
+			errs = append(errs, validate.NewFieldError(name, "cannot filter by this field", filter.Value))
+			continue
+		}
+
+		switch op := filter.Op; {
+		case op == "in":
+			var items []string
+			for _, item := range strings.Split(filter.Value, ",") {
+				items = append(items, strings.TrimSpace(item))
+			}
+			arg, parseErr = parseFilterValues(typ, items)
+			cond = filter.Field + " = ANY(" + argIdx + ")"
+
+		case op == "contains":
+			if typ != String {
+				errs = append(errs, validate.NewFieldError(name, "contains only works on text fields", filter.Value))
+				continue
+			}
+			arg = filter.Value
+			cond = "strpos(lower(" + filter.Field + "), lower(" + argIdx + ")) > 0"
+
+		case filterOps[op] != "":
+			if typ == Bool && op != "eq" && op != "ne" {
+				errs = append(errs, validate.NewFieldError(name, "unsupported filter operation", op))
+				continue
+			}
+			arg, parseErr = parseFilterValue(typ, filter.Value)
+			cond = filter.Field + " " + filterOps[op] + " " + argIdx
+
+		default:
+			errs = append(errs, validate.NewFieldError(name, "unsupported filter operation", op))
+			continue
+		}
+
+		if parseErr != nil {
+			errs = append(errs, validate.NewFieldError(name, parseErr.Error(), filter.Value))
+			continue
+		}
+
+		args = append(args, arg)
+		conds = append(conds, cond)
+	}
+
+	if err := validate.Multi(errs...); err != nil {
+		return "", nil, err
+	} else {
+		return strings.Join(conds, " AND "), args, nil
+	}
+}
+
+func parseFilterValues(typ FieldType, raws []string) (any, error) {
+	var (
+		ints   []int64
+		floats []float64
+		bools  []bool
+		times  []time.Time
+		strs   []string
+	)
+
+	for _, raw := range raws {
+		value, err := parseFilterValue(typ, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := value.(type) {
+		case int64:
+			ints = append(ints, v)
+		case float64:
+			floats = append(floats, v)
+		case bool:
+			bools = append(bools, v)
+		case time.Time:
+			times = append(times, v)
+		case string:
+			strs = append(strs, v)
+		}
+	}
+
+	switch typ {
+	case Int:
+		return ints, nil
+	case Float:
+		return floats, nil
+	case Bool:
+		return bools, nil
+	case Time:
+		return times, nil
+	default:
+		return strs, nil
+	}
+}
+
+func parseFilterValue(typ FieldType, raw string) (any, error) {
+	switch typ {
+	case Int:
+		if n, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, errInvalidValue("must be a whole number")
+		} else {
+			return n, nil
+		}
+
+	case Float:
+		if f, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, errInvalidValue("must be a number")
+		} else {
+			return f, nil
+		}
+
+	case Bool:
+		if b, err := strconv.ParseBool(raw); err != nil {
+			return nil, errInvalidValue("must be true or false")
+		} else {
+			return b, nil
+		}
+
+	case Time:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		} else if t, err := time.Parse(time.DateOnly, raw); err == nil {
+			return t, nil
+		} else {
+			return nil, errInvalidValue("must be a date or an RFC3339 timestamp")
+		}
+
+	default:
+		return raw, nil
+	}
+}
+
+type errInvalidValue string
+
+func (e errInvalidValue) Error() string { return string(e) }
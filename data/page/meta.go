@@ -10,6 +10,14 @@ import (
 type Meta struct {
 	Page        int `json:"page"`
 	RowsPerPage int `json:"rows_per_page"`
+
+	Sort    []Sort   `json:"sort,omitempty"`
+	Filters []Filter `json:"filters,omitempty"`
+
+	// Fields must be set by the caller to enable sorting and filtering, Select rejects any
+	// Sort or Filters on fields not listed here. When nil, Sort and Filters are ignored
+	// so existing endpoints are not affected by stray query parameters.
+	Fields Fields `json:"-"`
 }
 
 func FromRequest(req *http.Request) Meta {
@@ -55,5 +63,7 @@ func FromQuery(query url.Values) Meta {
 	return Meta{
 		Page:        page,
 		RowsPerPage: perPage,
+		Sort:        parseSorts(query["sort"]),
+		Filters:     parseFilters(query),
 	}
 }
@@ -0,0 +1,100 @@
+package page
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"fx.prodigy9.co/validate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromQuery_SortAndFilters(t *testing.T) {
+	meta := FromQuery(url.Values{
+		"sort":                    {"-created_at, title", "+id"},
+		"filter[status]":          {"done"},
+		"filter[created_at][GTE]": {"2024-01-01"},
+		"filter[a][b][c]":         {"ignored"},
+	})
+
+	require.Equal(t, []Sort{
+		{Field: "created_at", Desc: true},
+		{Field: "title"},
+		{Field: "id"},
+	}, meta.Sort)
+	require.Equal(t, []Filter{
+		{Field: "created_at", Op: "gte", Value: "2024-01-01"},
+		{Field: "status", Op: "eq", Value: "done"},
+	}, meta.Filters)
+}
+
+func TestMeta_OrderBy(t *testing.T) {
+	meta := Meta{
+		Sort:   []Sort{{Field: "created_at", Desc: true}, {Field: "id"}},
+		Fields: Fields{"created_at": Time, "id": Int},
+	}
+
+	orderBy, err := meta.orderBy()
+	require.NoError(t, err)
+	require.Equal(t, "created_at DESC, id ASC", orderBy)
+
+	meta.Fields = nil
+	orderBy, err = meta.orderBy()
+	require.NoError(t, err)
+	require.Empty(t, orderBy)
+
+	meta.Fields = Fields{"created_at": Time, "id": Int}
+	meta.Sort = append(meta.Sort, Sort{Field: "password"})
+	_, err = meta.orderBy()
+	require.IsType(t, &validate.Error{}, err)
+	require.Contains(t, err.(*validate.Error).Fields, "sort")
+}
+
+func TestMeta_Where(t *testing.T) {
+	meta := Meta{
+		Filters: []Filter{
+			{Field: "created_at", Op: "gte", Value: "2024-01-01"},
+			{Field: "id", Op: "in", Value: "1, 2,3"},
+			{Field: "title", Op: "contains", Value: "milk"},
+			{Field: "done", Op: "eq", Value: "true"},
+		},
+		Fields: Fields{"created_at": Time, "id": Int, "title": String, "done": Bool},
+	}
+
+	where, args, err := meta.where([]any{"user"})
+	require.NoError(t, err)
+	require.Equal(t, "created_at >= $2"+
+		" AND id = ANY($3)"+
+		" AND strpos(lower(title), lower($4)) > 0"+
+		" AND done = $5", where)
+	require.Equal(t, []any{
+		"user",
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		[]int64{1, 2, 3},
+		"milk",
+		true,
+	}, args)
+}
+
+func TestMeta_Where_Invalid(t *testing.T) {
+	meta := Meta{
+		Filters: []Filter{
+			{Field: "secret", Op: "eq", Value: "x"},
+			{Field: "id", Op: "eq", Value: "abc"},
+			{Field: "id", Op: "contains", Value: "1"},
+			{Field: "done", Op: "gt", Value: "true"},
+			{Field: "title", Op: "regex", Value: ".*"},
+		},
+		Fields: Fields{"id": Int, "title": String, "done": Bool},
+	}
+
+	_, _, err := meta.where(nil)
+	require.IsType(t, &validate.Error{}, err)
+
+	fields := err.(*validate.Error).Fields
+	require.Len(t, fields, 4)
+	require.Len(t, fields["filter[id]"], 2)
+	require.Contains(t, fields, "filter[secret]")
+	require.Contains(t, fields, "filter[done]")
+	require.Contains(t, fields, "filter[title]")
+}
@@ -3,6 +3,7 @@ package page
 import (
 	"context"
 	"errors"
+	"slices"
 	"strconv"
 
 	"fx.prodigy9.co/data"
@@ -43,6 +44,15 @@ func Select[T any](ctx context.Context, out *Page[T], meta Meta, sql string, arg
 		meta.Page = 1
 	}
 
+	where, args, err := meta.where(slices.Clone(args))
+	if err != nil {
+		return err
+	}
+	orderBy, err := meta.orderBy()
+	if err != nil {
+		return err
+	}
+
 	scope, cancel, err := data.NewScopeErr(ctx, &err)
 	if err != nil {
 		return err
@@ -55,10 +65,19 @@ func Select[T any](ctx context.Context, out *Page[T], meta Meta, sql string, arg
 
 		prefix   = "WITH source AS (" + sql + ")\n"
 		countSQL = prefix + "SELECT COUNT(*) FROM source"
-		dataSQL  = prefix + "SELECT * FROM source" +
-			" LIMIT $" + strconv.Itoa(limitArgIdx) +
-			" OFFSET $" + strconv.Itoa(offsetArgidx)
+		dataSQL  = prefix + "SELECT * FROM source"
+	)
+	if where != "" {
+		countSQL += " WHERE " + where
+		dataSQL += " WHERE " + where
+	}
+	if orderBy != "" {
+		dataSQL += " ORDER BY " + orderBy
+	}
+	dataSQL += " LIMIT $" + strconv.Itoa(limitArgIdx) +
+		" OFFSET $" + strconv.Itoa(offsetArgidx)
 
+	var (
 		count    int
 		offset   = (meta.Page - 1) * meta.RowsPerPage
 		dataArgs = append(args, meta.RowsPerPage, offset)
@@ -0,0 +1,57 @@
+package page
+
+import (
+	"strings"
+
+	"fx.prodigy9.co/validate"
+)
+
+// Sort is a single sort=... entry, e.g. `sort=-created_at,title` parses into
+// [{created_at true} {title false}].
+type Sort struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+func parseSorts(values []string) (sorts []Sort) {
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			switch {
+			case item == "", item == "-", item == "+":
+				continue
+			case strings.HasPrefix(item, "-"):
+				sorts = append(sorts, Sort{Field: item[1:], Desc: true})
+			default:
+				sorts = append(sorts, Sort{Field: strings.TrimPrefix(item, "+")})
+			}
+		}
+	}
+	return sorts
+}
+
+func (m Meta) orderBy() (string, error) {
+	if m.Fields == nil {
+		return "", nil
+	}
+
+	var (
+		errs   []error
+		orders []string
+	)
+	for _, sort := range m.Sort {
+		if _, ok := m.Fields[sort.Field]; !ok || !identRx.MatchString(sort.Field) {
+			errs = append(errs, validate.NewFieldError("sort", "cannot sort by this field", sort.Field))
+		} else if sort.Desc {
+			orders = append(orders, sort.Field+" DESC")
+		} else {
+			orders = append(orders, sort.Field+" ASC")
+		}
+	}
+
+	if err := validate.Multi(errs...); err != nil {
+		return "", err
+	} else {
+		return strings.Join(orders, ", "), nil
+	}
+}
@@ -0,0 +1,106 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+	"weak"
+
+	"fx.prodigy9.co/fxlog"
+)
+
+// PoolHook receives periodic connection pool statistics, e.g. to export them as metrics.
+// It is called every DATABASE_POOL_STATS interval for every connected *sqlx.DB.
+type PoolHook func(stats sql.DBStats)
+
+var (
+	poolHooksMutex sync.RWMutex
+	poolHooks      []PoolHook
+)
+
+// AddPoolHook registers a PoolHook. Hooks accumulate and are called in the order they are
+// added.
+func AddPoolHook(hook PoolHook) {
+	poolHooksMutex.Lock()
+	defer poolHooksMutex.Unlock()
+	poolHooks = append(poolHooks, hook)
+}
+
+func currentPoolHooks() []PoolHook {
+	poolHooksMutex.RLock()
+	defer poolHooksMutex.RUnlock()
+	return poolHooks
+}
+
+// watchPool samples db's statistics every interval until db is closed, or garbage
+// collected without being closed. Only a weak reference is kept so that watching does not
+// keep the pool alive.
+func watchPool(db *sql.DB, interval time.Duration) {
+	ref := weak.Make(db)
+	prev := db.Stats()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		db := ref.Value()
+		if db == nil || poolClosed(db) {
+			return
+		}
+
+		stats := db.Stats()
+		reportPool(prev, stats)
+		prev = stats
+	}
+}
+
+// poolClosed reports whether db.Close has been called. database/sql has no way to ask
+// directly, but taking a connection with a cancelled context fails with its "database is
+// closed" error before it looks at the context, and never touches the pool otherwise.
+func poolClosed(db *sql.DB) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	conn, err := db.Conn(ctx)
+	if conn != nil {
+		_ = conn.Close()
+	}
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+func reportPool(prev, stats sql.DBStats) {
+	for _, hook := range currentPoolHooks() {
+		hook(stats)
+	}
+
+	fxlog.Log("connection pool stats",
+		fxlog.Int("max_open", stats.MaxOpenConnections),
+		fxlog.Int("open", stats.OpenConnections),
+		fxlog.Int("in_use", stats.InUse),
+		fxlog.Int("idle", stats.Idle),
+		fxlog.Int64("wait_count", stats.WaitCount),
+		fxlog.Duration("wait_duration", stats.WaitDuration),
+		fxlog.Int64("max_idle_closed", stats.MaxIdleClosed),
+		fxlog.Int64("max_idle_time_closed", stats.MaxIdleTimeClosed),
+		fxlog.Int64("max_lifetime_closed", stats.MaxLifetimeClosed),
+	)
+
+	if waits, waited, ok := poolSaturated(prev, stats); ok {
+		fxlog.Log("connection pool saturated, consider raising DATABASE_MAX_OPEN",
+			fxlog.Int("max_open", stats.MaxOpenConnections),
+			fxlog.Int("in_use", stats.InUse),
+			fxlog.Int64("waits", waits),
+			fxlog.Duration("waited", waited),
+		)
+	}
+}
+
+// poolSaturated reports whether queries had to wait for a free connection between two
+// samples, along with how many did and for how long in total.
+func poolSaturated(prev, stats sql.DBStats) (int64, time.Duration, bool) {
+	waits := stats.WaitCount - prev.WaitCount
+	waited := stats.WaitDuration - prev.WaitDuration
+	return waits, waited, waits > 0
+}
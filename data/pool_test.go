@@ -0,0 +1,55 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolSaturated(t *testing.T) {
+	prev := sql.DBStats{WaitCount: 3, WaitDuration: time.Second}
+
+	_, _, saturated := poolSaturated(prev, prev)
+	require.False(t, saturated)
+
+	waits, waited, saturated := poolSaturated(prev, sql.DBStats{WaitCount: 5, WaitDuration: 3 * time.Second})
+	require.True(t, saturated)
+	require.Equal(t, int64(2), waits)
+	require.Equal(t, 2*time.Second, waited)
+}
+
+func TestReportPool(t *testing.T) {
+	saved := poolHooks
+	t.Cleanup(func() { poolHooks = saved })
+	poolHooks = nil
+
+	var got []sql.DBStats
+	AddPoolHook(func(stats sql.DBStats) { got = append(got, stats) })
+
+	stats := sql.DBStats{MaxOpenConnections: 4, InUse: 4, WaitCount: 1}
+	reportPool(sql.DBStats{}, stats)
+	require.Equal(t, []sql.DBStats{stats}, got)
+}
+
+func TestWatchPool_Closed(t *testing.T) {
+	db, err := sql.Open("pgx", "postgres://localhost/fx_pool_test")
+	require.NoError(t, err)
+	require.False(t, poolClosed(db))
+
+	done := make(chan struct{})
+	go func() {
+		watchPool(db, time.Millisecond)
+		close(done)
+	}()
+
+	require.NoError(t, db.Close())
+	require.True(t, poolClosed(db))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchPool kept running after the pool was closed")
+	}
+}
@@ -2,8 +2,15 @@ package data
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -14,10 +21,21 @@ type (
 		// End ends the scope. It is meant to be used inside a `defer` statement.
 		End(*error)
 
+		// AfterCommit registers a function to be called after the root transaction
+		// successfully commits. AfterRollback is its counterpart for when the root
+		// transaction is rolled back or fails to commit. Functions registered in child
+		// scopes are deferred to the root scope's outcome.
+		//
+		// The transaction has ended by the time these are called so they must not use
+		// the scope or its Context() for database work.
+		AfterCommit(func())
+		AfterRollback(func())
+
 		Get(dest interface{}, sql string, args ...interface{}) error
 		Select(dest interface{}, sql string, args ...interface{}) error
 		Exec(sql string, args ...interface{}) error
 		Prepare(query string) (*sqlx.Stmt, error)
+		CopyFrom(table string, columns []string, rows pgx.CopyFromSource) (int64, error)
 
 		GetSQL(interface{}, SQLGenerator) error
 		SelectSQL(interface{}, SQLGenerator) error
@@ -25,31 +43,53 @@ type (
 		PrepareSQL(SQLGenerator) (*sqlx.Stmt, error)
 	}
 
-	txKey struct{}
+	txKey        struct{}
+	savepointKey struct{}
+
+	// txState is shared between a root scope and all of its children.
+	txState struct {
+		conn *sqlx.Conn
+		tx   *sqlx.Tx
+
+		// rollbackOnly makes the root scope roll back even when it ends without an error,
+		// and wraps its direct children in savepoints, see NewRollbackScope.
+		rollbackOnly bool
+		savepoints   int
+
+		mutex         sync.Mutex
+		afterCommit   []func()
+		afterRollback []func()
+	}
 
 	scopeImpl struct {
-		ctx    context.Context
-		cancel context.CancelFunc
-		tx     *sqlx.Tx
-		child  bool
+		ctx       context.Context
+		cancel    context.CancelFunc
+		state     *txState
+		tx        *sqlx.Tx
+		child     bool
+		savepoint string
 	}
 )
 
-var _ Scope = scopeImpl{}
+var (
+	ErrCopyUnsupported = errors.New("data: COPY requires a pgx connection")
 
-func getTx(ctx context.Context) (*sqlx.Tx, bool) {
-	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
-		return tx, true
+	_ Scope = scopeImpl{}
+)
+
+func getTx(ctx context.Context) (*txState, bool) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state, true
 	} else {
 		return nil, false
 	}
 }
 
-func setTx(ctx context.Context, tx *sqlx.Tx) context.Context {
-	return context.WithValue(ctx, txKey{}, tx)
+func setTx(ctx context.Context, state *txState) context.Context {
+	return context.WithValue(ctx, txKey{}, state)
 }
 
-func newScope(ctx context.Context, db *sqlx.DB) (scope scopeImpl, err error) {
+func newScope(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, rollbackOnly bool) (scope scopeImpl, err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("tx: %w", err)
@@ -57,47 +97,157 @@ func newScope(ctx context.Context, db *sqlx.DB) (scope scopeImpl, err error) {
 	}()
 
 	scope.ctx, scope.cancel = context.WithCancel(ctx)
-	scope.tx, scope.child = getTx(ctx)
+	scope.state, scope.child = getTx(ctx)
 	if !scope.child {
-		if scope.tx, err = db.BeginTxx(ctx, nil); err != nil {
-			// not a child, first call, start new tx
+		// not a child, first call, start new tx on a dedicated connection so driver-level
+		// features (e.g. COPY) can be used within the same transaction.
+		var (
+			conn *sqlx.Conn
+			tx   *sqlx.Tx
+		)
+		if conn, err = db.Connx(ctx); err != nil {
+			return scopeImpl{}, err
+		} else if tx, err = conn.BeginTxx(ctx, opts); err != nil {
+			_ = conn.Close()
 			return scopeImpl{}, err
 		}
+		scope.state = &txState{conn: conn, tx: tx, rollbackOnly: rollbackOnly}
+	} else if scope.state.rollbackOnly && ctx.Value(savepointKey{}) == nil {
+		// direct children stand in for the top-level transactions they would run in
+		// outside of the root, so a failing one must not leave everything after it in an
+		// aborted transaction (25P02). Deeper children share their parent's savepoint and
+		// fail the way they would in a real transaction.
+		scope.savepoint = scope.state.nextSavepoint()
+		if _, err = scope.state.tx.ExecContext(ctx, "SAVEPOINT "+scope.savepoint); err != nil {
+			scope.cancel()
+			return scopeImpl{}, err
+		}
+		scope.ctx = context.WithValue(scope.ctx, savepointKey{}, scope.savepoint)
 	}
 
-	scope.ctx = setTx(scope.ctx, scope.tx)
+	scope.tx = scope.state.tx
+	scope.ctx = setTx(scope.ctx, scope.state)
 	return
 }
 
 func (s scopeImpl) Context() context.Context { return s.ctx }
 
 func (s scopeImpl) End(err *error) {
+	var hooks []func()
 	if !s.child {
-		if *err == nil {
+		committed := false
+		if *err == nil && !s.state.rollbackOnly {
 			*err = s.tx.Commit()
+			committed = *err == nil
 		} else {
 			_ = s.tx.Rollback()
 		}
+		if s.state.conn != nil {
+			_ = s.state.conn.Close()
+		}
+		hooks = s.state.hooks(committed)
+	} else if s.savepoint != "" {
+		if *err != nil {
+			_, _ = s.tx.Exec("ROLLBACK TO SAVEPOINT " + s.savepoint)
+		} else if _, releaseErr := s.tx.Exec("RELEASE SAVEPOINT " + s.savepoint); releaseErr != nil {
+			_, _ = s.tx.Exec("ROLLBACK TO SAVEPOINT " + s.savepoint)
+			*err = fmt.Errorf("tx: %w", releaseErr)
+		}
 	}
 	if s.cancel != nil {
 		s.cancel()
 	}
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+func (s scopeImpl) AfterCommit(hook func()) {
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
+	s.state.afterCommit = append(s.state.afterCommit, hook)
+}
+func (s scopeImpl) AfterRollback(hook func()) {
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
+	s.state.afterRollback = append(s.state.afterRollback, hook)
+}
+
+func (t *txState) nextSavepoint() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.savepoints++
+	return "fx_savepoint_" + strconv.Itoa(t.savepoints)
+}
+
+func (t *txState) hooks(committed bool) []func() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	hooks := t.afterRollback
+	if committed {
+		hooks = t.afterCommit
+	}
+	t.afterCommit, t.afterRollback = nil, nil
+	return hooks
 }
 
 func (s scopeImpl) Get(dest interface{}, sql string, args ...interface{}) error {
-	return s.tx.GetContext(s.ctx, dest, sql, args...)
+	return instrument(s.ctx, sql, len(args), func(ctx context.Context) (int64, error) {
+		if err := s.tx.GetContext(ctx, dest, sql, args...); err != nil {
+			return 0, err
+		} else {
+			return 1, nil
+		}
+	})
 }
 func (s scopeImpl) Select(dest interface{}, sql string, args ...interface{}) error {
-	return s.tx.SelectContext(s.ctx, dest, sql, args...)
+	return instrument(s.ctx, sql, len(args), func(ctx context.Context) (int64, error) {
+		if err := s.tx.SelectContext(ctx, dest, sql, args...); err != nil {
+			return 0, err
+		} else {
+			return countRows(dest), nil
+		}
+	})
 }
 func (s scopeImpl) Exec(sql string, args ...interface{}) error {
-	_, err := s.tx.ExecContext(s.ctx, sql, args...)
-	return err
+	return instrument(s.ctx, sql, len(args), func(ctx context.Context) (int64, error) {
+		if result, err := s.tx.ExecContext(ctx, sql, args...); err != nil {
+			return 0, err
+		} else if rows, err := result.RowsAffected(); err != nil {
+			return 0, nil // rows are informational, don't fail the statement
+		} else {
+			return rows, nil
+		}
+	})
 }
 func (s scopeImpl) Prepare(query string) (*sqlx.Stmt, error) {
 	return s.tx.Preparex(query)
 }
 
+// CopyFrom bulk loads rows with Postgres' COPY protocol on the scope's connection, so the
+// rows are part of the transaction.
+func (s scopeImpl) CopyFrom(table string, columns []string, rows pgx.CopyFromSource) (n int64, err error) {
+	if s.state.conn == nil {
+		return 0, ErrCopyUnsupported
+	}
+
+	sql := "COPY " + quoteIdent(table) + " (" + strings.Join(columns, ", ") + ") FROM STDIN"
+	err = instrument(s.ctx, sql, 0, func(ctx context.Context) (int64, error) {
+		err := s.state.conn.Raw(func(driverConn any) error {
+			conn, ok := driverConn.(*stdlib.Conn)
+			if !ok {
+				return ErrCopyUnsupported
+			}
+
+			n, err = conn.Conn().CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, rows)
+			return err
+		})
+		return n, err
+	})
+	return n, err
+}
+
 func (s scopeImpl) GetSQL(out interface{}, sqlgen SQLGenerator) (err error) {
 	sql, args := sqlgen.Sql()
 	return s.Get(out, sql, args...)
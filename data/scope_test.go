@@ -0,0 +1,30 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope_Hooks(t *testing.T) {
+	state := &txState{}
+	root := scopeImpl{state: state}
+	child := scopeImpl{state: state, child: true}
+
+	var calls []string
+	root.AfterCommit(func() { calls = append(calls, "root commit") })
+	child.AfterCommit(func() { calls = append(calls, "child commit") })
+	child.AfterRollback(func() { calls = append(calls, "child rollback") })
+
+	// child scopes never fire hooks, they belong to the root's outcome
+	var err error
+	child.End(&err)
+	require.Empty(t, calls)
+
+	for _, hook := range state.hooks(true) {
+		hook()
+	}
+	require.Equal(t, []string{"root commit", "child commit"}, calls)
+	require.Empty(t, state.hooks(true))
+	require.Empty(t, state.hooks(false))
+}
@@ -0,0 +1,251 @@
+package seed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fx.prodigy9.co/config"
+	"github.com/jackc/pgx/v5"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	SeedPathConfig = config.StrDef("DATABASE_SEEDS", "seeds")
+
+	embeddedSeeds []fs.FS
+)
+
+// Table is a table's worth of fixture rows, upserted on the Key columns so loading the
+// same fixture twice leaves a single copy of each row. Key defaults to `id`.
+type Table struct {
+	Table string           `json:"table" yaml:"table"`
+	Key   []string         `json:"key" yaml:"key"`
+	Rows  []map[string]any `json:"rows" yaml:"rows"`
+}
+
+// fixture is the layout of a fixture file, e.g. in YAML:
+//
+//	environments: [development, staging]
+//	tables:
+//	  - table: users
+//	    key: [email]
+//	    rows:
+//	      - email: admin@example.com
+//	        name: Admin
+//
+// Tables are loaded in file order, so list referenced tables first.
+type fixture struct {
+	Environments []string `json:"environments" yaml:"environments"`
+	Tables       []Table  `json:"tables" yaml:"tables"`
+}
+
+// Embed adds fixture files embedded into the binary, for seeding demo data on servers
+// which do not have the source tree. Like migrator.Embed, it may be called multiple times.
+func Embed(fsys fs.FS) {
+	embeddedSeeds = append(embeddedSeeds, fsys)
+}
+
+// LoadAuto loads the fixture files from the DATABASE_SEEDS directory (`seeds` by default)
+// if it exists, the embedded fixtures otherwise, and merges in the Go seeders added with
+// Register. Seeds are sorted by name, prefix them with numbers to control the order they
+// run in.
+func LoadAuto(cfg *config.Source) ([]Seed, error) {
+	var (
+		seeds []Seed
+		dir   = config.Get(cfg, SeedPathConfig)
+	)
+	if _, err := os.Stat(dir); err == nil {
+		if seeds, err = LoadDir(dir); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("seed: %w", err)
+	} else {
+		for _, fsys := range embeddedSeeds {
+			part, err := LoadFS(fsys)
+			if err != nil {
+				return nil, err
+			}
+			seeds = append(seeds, part...)
+		}
+	}
+
+	seeds = append(seeds, goSeeds...)
+	if len(seeds) == 0 {
+		return nil, ErrNoSeeds
+	}
+
+	sort.SliceStable(seeds, func(i, j int) bool { return seeds[i].Name < seeds[j].Name })
+	for idx := 1; idx < len(seeds); idx++ {
+		if seeds[idx].Name == seeds[idx-1].Name {
+			return nil, fmt.Errorf("seed: duplicate seed %s", seeds[idx].Name)
+		}
+	}
+	return seeds, nil
+}
+
+func LoadDir(dir string) ([]Seed, error) {
+	seeds, err := LoadFS(os.DirFS(dir))
+	for idx := range seeds {
+		seeds[idx].Path = filepath.Join(dir, seeds[idx].Path)
+	}
+	return seeds, err
+}
+
+// LoadFS loads every .yaml, .yml and .json fixture file in fsys, named after the file
+// without its extension.
+func LoadFS(fsys fs.FS) (seeds []Seed, err error) {
+	err = fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !isFixture(path) {
+			return err
+		}
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("seed: %w", err)
+		}
+
+		seed, err := ParseFixture(path, content)
+		if err != nil {
+			return err
+		}
+		seeds = append(seeds, seed)
+		return nil
+	})
+	return seeds, err
+}
+
+// LoadFile loads a single fixture file.
+func LoadFile(path string) (Seed, error) {
+	if content, err := os.ReadFile(path); err != nil {
+		return Seed{}, fmt.Errorf("seed: %w", err)
+	} else {
+		return ParseFixture(path, content)
+	}
+}
+
+// ParseFixture parses a YAML or JSON fixture, picked by the extension of path. Nested
+// objects and lists in row values are stored as JSON, for json and jsonb columns.
+func ParseFixture(path string, content []byte) (Seed, error) {
+	var (
+		fix fixture
+		err error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(content))
+		dec.UseNumber()
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&fix)
+	default:
+		dec := yaml.NewDecoder(bytes.NewReader(content))
+		dec.KnownFields(true)
+		err = dec.Decode(&fix)
+	}
+	if err != nil {
+		return Seed{}, fmt.Errorf("seed: %s: %w", path, err)
+	}
+
+	for idx, table := range fix.Tables {
+		if table.Table == "" {
+			return Seed{}, fmt.Errorf("seed: %s: table %d has no name", path, idx+1)
+		}
+		if len(table.Key) == 0 {
+			fix.Tables[idx].Key = []string{"id"}
+		}
+		for _, row := range table.Rows {
+			for col, value := range row {
+				if row[col], err = columnValue(value); err != nil {
+					return Seed{}, fmt.Errorf("seed: %s: %s.%s: %w", path, table.Table, col, err)
+				}
+			}
+		}
+	}
+
+	base := filepath.Base(path)
+	return Seed{
+		Name:         strings.TrimSuffix(base, filepath.Ext(base)),
+		Path:         path,
+		Environments: fix.Environments,
+		Tables:       fix.Tables,
+	}, nil
+}
+
+func isFixture(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+func columnValue(value any) (any, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	case map[string]any, []any:
+		bytes, err := json.Marshal(v)
+		return string(bytes), err
+	default:
+		return v, nil
+	}
+}
+
+// upsertSQL writes a single row, rows may have different columns so they are not batched.
+func (t Table) upsertSQL(row map[string]any) (string, []any, error) {
+	for _, key := range t.Key {
+		if _, ok := row[key]; !ok {
+			return "", nil, fmt.Errorf("missing key column %s", key)
+		}
+	}
+
+	columns := make([]string, 0, len(row))
+	for col := range row {
+		columns = append(columns, col)
+	}
+	slices.Sort(columns)
+
+	var (
+		names   = make([]string, len(columns))
+		params  = make([]string, len(columns))
+		updates []string
+		args    = make([]any, len(columns))
+	)
+	for idx, col := range columns {
+		names[idx] = pgx.Identifier{col}.Sanitize()
+		params[idx] = "$" + strconv.Itoa(idx+1)
+		args[idx] = row[col]
+		if !slices.Contains(t.Key, col) {
+			updates = append(updates, names[idx]+" = EXCLUDED."+names[idx])
+		}
+	}
+
+	keys := make([]string, len(t.Key))
+	for idx, key := range t.Key {
+		keys[idx] = pgx.Identifier{key}.Sanitize()
+	}
+
+	sql := "INSERT INTO " + pgx.Identifier(strings.Split(t.Table, ".")).Sanitize() +
+		" (" + strings.Join(names, ", ") + ")" +
+		" VALUES (" + strings.Join(params, ", ") + ")" +
+		" ON CONFLICT (" + strings.Join(keys, ", ") + ")"
+	if len(updates) == 0 {
+		sql += " DO NOTHING"
+	} else {
+		sql += " DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	return sql, args, nil
+}
@@ -0,0 +1,166 @@
+// Package seed loads development and demo data into the database from fixture files and
+// Go seeders. Seeds are idempotent, fixture rows are upserted by key so running a seed
+// again updates the rows instead of duplicating them, and each seed is scoped to the
+// environments it is meant for.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"fx.prodigy9.co/config"
+	"fx.prodigy9.co/data"
+)
+
+var (
+	// EnvironmentConfig names the environment seeds are run in, seeds which are not
+	// enabled for it are skipped.
+	EnvironmentConfig = config.StrDef("ENVIRONMENT", "development")
+
+	// DefaultEnvironments is used for seeds that do not list their environments, so demo
+	// data never reaches production by accident.
+	DefaultEnvironments = []string{"development", "test"}
+
+	ErrNoSeeds     = errors.New("seed: no seeds found")
+	ErrUnknownSeed = errors.New("seed: unknown seed")
+
+	goSeeds []Seed
+)
+
+// Func is the body of a Go seeder. The context carries the seeding transaction so that
+// data.Exec, data.UpsertBatch etc. called with it run inside that transaction. Like
+// fixtures, seeders should be safe to run more than once.
+type Func func(ctx context.Context) error
+
+// Seed is a named set of data, either fixture tables loaded from a file or a Go Func.
+type Seed struct {
+	Name         string
+	Path         string
+	Environments []string
+	Tables       []Table
+	Func         Func
+}
+
+// Register adds a seeder implemented in Go, for data that is easier to build in code,
+// e.g. users with hashed passwords. Without environments, DefaultEnvironments are used.
+// Call it from an init function:
+//
+//	func init() {
+//		seed.Register("demo_users", seedDemoUsers, "development", "staging")
+//	}
+//
+// Register panics on duplicate names.
+func Register(name string, fn Func, environments ...string) {
+	if name == "" || fn == nil {
+		panic("seed: Register requires a name and a function")
+	}
+	if slices.ContainsFunc(goSeeds, func(s Seed) bool { return s.Name == name }) {
+		panic("seed: duplicate seed " + name)
+	}
+
+	goSeeds = append(goSeeds, Seed{
+		Name:         name,
+		Environments: environments,
+		Func:         fn,
+	})
+}
+
+// IsGo reports whether the seed is a Go seeder rather than a fixture file.
+func (s Seed) IsGo() bool { return s.Func != nil }
+
+// EnabledIn reports whether the seed should run in env.
+func (s Seed) EnabledIn(env string) bool {
+	if len(s.Environments) == 0 {
+		return slices.Contains(DefaultEnvironments, env)
+	}
+	return slices.Contains(s.Environments, env)
+}
+
+// Select picks the seeds to run in env. Without names, that is every seed enabled in
+// env in name order. Named seeds are returned in the given order and must exist and be
+// enabled in env.
+func Select(seeds []Seed, env string, names ...string) ([]Seed, error) {
+	if len(names) == 0 {
+		var result []Seed
+		for _, seed := range seeds {
+			if seed.EnabledIn(env) {
+				result = append(result, seed)
+			}
+		}
+		return result, nil
+	}
+
+	result := make([]Seed, 0, len(names))
+	for _, name := range names {
+		idx := slices.IndexFunc(seeds, func(s Seed) bool { return s.Name == name })
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSeed, name)
+		} else if !seeds[idx].EnabledIn(env) {
+			return nil, fmt.Errorf("seed: %s is not enabled for the %s environment", name, env)
+		}
+		result = append(result, seeds[idx])
+	}
+	return result, nil
+}
+
+// Run loads every seed (see LoadAuto) and applies the ones picked by Select for the
+// configured environment.
+func Run(ctx context.Context, names ...string) ([]Seed, error) {
+	cfg := config.FromContext(ctx)
+	seeds, err := LoadAuto(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if seeds, err = Select(seeds, config.Get(cfg, EnvironmentConfig), names...); err != nil {
+		return nil, err
+	}
+	return seeds, Apply(ctx, seeds...)
+}
+
+// Apply runs the seeds in a single transaction, in the ambient Scope if there is one, so
+// that either all of them are applied or none.
+func Apply(ctx context.Context, seeds ...Seed) error {
+	return data.Run(ctx, func(s data.Scope) error {
+		for _, seed := range seeds {
+			if err := seed.apply(s); err != nil {
+				return fmt.Errorf("seed: %s: %w", seed.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s Seed) apply(scope data.Scope) error {
+	if s.IsGo() {
+		return s.Func(scope.Context())
+	}
+
+	for _, table := range s.Tables {
+		for idx, row := range table.Rows {
+			sql, args, err := table.upsertSQL(row)
+			if err != nil {
+				return fmt.Errorf("%s row %d: %w", table.Table, idx+1, err)
+			} else if err := scope.Exec(sql, args...); err != nil {
+				return fmt.Errorf("%s row %d: %w", table.Table, idx+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s Seed) String() string {
+	envs := s.Environments
+	if len(envs) == 0 {
+		envs = DefaultEnvironments
+	}
+
+	kind := s.Path
+	if s.IsGo() {
+		kind = "(go)"
+	}
+	return s.Name + " " + kind + " [" + strings.Join(envs, ", ") + "]"
+}
@@ -0,0 +1,133 @@
+package seed
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"fx.prodigy9.co/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFixture(t *testing.T) {
+	yamlSeed, err := ParseFixture("seeds/10_users.yaml", []byte(`
+environments: [development, staging]
+tables:
+  - table: users
+    key: [email]
+    rows:
+      - email: admin@example.com
+        age: 42
+        settings: {theme: dark}
+  - table: public.todos
+    rows:
+      - id: 1
+        tags: [a, b]
+`))
+	require.NoError(t, err)
+	require.Equal(t, "10_users", yamlSeed.Name)
+	require.Equal(t, []string{"development", "staging"}, yamlSeed.Environments)
+	require.Len(t, yamlSeed.Tables, 2)
+	require.Equal(t, []string{"email"}, yamlSeed.Tables[0].Key)
+	require.Equal(t, []string{"id"}, yamlSeed.Tables[1].Key)
+	require.Equal(t, 42, yamlSeed.Tables[0].Rows[0]["age"])
+	require.Equal(t, `{"theme":"dark"}`, yamlSeed.Tables[0].Rows[0]["settings"])
+	require.Equal(t, `["a","b"]`, yamlSeed.Tables[1].Rows[0]["tags"])
+
+	jsonSeed, err := ParseFixture("todos.json", []byte(`{
+		"tables": [{"table": "todos", "rows": [{"id": 1, "score": 1.5}]}]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "todos", jsonSeed.Name)
+	require.Equal(t, int64(1), jsonSeed.Tables[0].Rows[0]["id"])
+	require.Equal(t, 1.5, jsonSeed.Tables[0].Rows[0]["score"])
+
+	_, err = ParseFixture("bad.yaml", []byte("tables:\n  - rows: []\n"))
+	require.ErrorContains(t, err, "has no name")
+	_, err = ParseFixture("bad.json", []byte(`{"table": "todos"}`))
+	require.Error(t, err)
+}
+
+func TestUpsertSQL(t *testing.T) {
+	table := Table{Table: "public.users", Key: []string{"email"}}
+
+	sql, args, err := table.upsertSQL(map[string]any{"name": "Admin", "email": "admin@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, `INSERT INTO "public"."users" ("email", "name") VALUES ($1, $2)`+
+		` ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name"`, sql)
+	require.Equal(t, []any{"admin@example.com", "Admin"}, args)
+
+	sql, _, err = table.upsertSQL(map[string]any{"email": "admin@example.com"})
+	require.NoError(t, err)
+	require.Contains(t, sql, "DO NOTHING")
+
+	_, _, err = table.upsertSQL(map[string]any{"name": "Admin"})
+	require.ErrorContains(t, err, "missing key column email")
+}
+
+func TestSelect(t *testing.T) {
+	seeds := []Seed{
+		{Name: "demo"},
+		{Name: "staging", Environments: []string{"staging"}},
+		{Name: "users", Environments: []string{"development", "staging"}},
+	}
+	names := func(seeds []Seed) (names []string) {
+		for _, seed := range seeds {
+			names = append(names, seed.Name)
+		}
+		return names
+	}
+
+	result, err := Select(seeds, "development")
+	require.NoError(t, err)
+	require.Equal(t, []string{"demo", "users"}, names(result))
+
+	result, err = Select(seeds, "staging")
+	require.NoError(t, err)
+	require.Equal(t, []string{"staging", "users"}, names(result))
+
+	result, err = Select(seeds, "production")
+	require.NoError(t, err)
+	require.Empty(t, result)
+
+	result, err = Select(seeds, "staging", "users", "staging")
+	require.NoError(t, err)
+	require.Equal(t, []string{"users", "staging"}, names(result))
+
+	_, err = Select(seeds, "production", "demo")
+	require.ErrorContains(t, err, "not enabled for the production environment")
+	_, err = Select(seeds, "development", "nope")
+	require.ErrorIs(t, err, ErrUnknownSeed)
+}
+
+func TestLoadAuto(t *testing.T) {
+	prevGo, prevEmbedded := goSeeds, embeddedSeeds
+	t.Cleanup(func() { goSeeds, embeddedSeeds = prevGo, prevEmbedded })
+	goSeeds, embeddedSeeds = nil, nil
+
+	cfg := config.NewSource(&config.MemProvider{}, config.DefaultSource().Vars())
+	config.Set(cfg, SeedPathConfig, t.TempDir()+"/missing")
+
+	_, err := LoadAuto(cfg)
+	require.ErrorIs(t, err, ErrNoSeeds)
+
+	Embed(fstest.MapFS{
+		"seeds/20_todos.yml": {Data: []byte("tables: []")},
+		"seeds/README.md":    {Data: []byte("not a fixture")},
+	})
+	Register("10_users", func(ctx context.Context) error { return nil })
+
+	seeds, err := LoadAuto(cfg)
+	require.NoError(t, err)
+	require.Len(t, seeds, 2)
+	require.Equal(t, "10_users", seeds[0].Name)
+	require.True(t, seeds[0].IsGo())
+	require.Equal(t, "20_todos", seeds[1].Name)
+	require.Equal(t, "seeds/20_todos.yml", seeds[1].Path)
+
+	Register("20_todos", func(ctx context.Context) error { return nil })
+	_, err = LoadAuto(cfg)
+	require.ErrorContains(t, err, "duplicate seed 20_todos")
+	require.Panics(t, func() { Register("20_todos", func(ctx context.Context) error { return nil }) })
+}
@@ -0,0 +1,116 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	DefaultMaxAttempts = 3
+
+	// SQLSTATE codes that signal the transaction was aborted through no fault of its own
+	// and can be safely retried from the start.
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+var (
+	retryBaseDelay = 10 * time.Millisecond
+	retryMaxDelay  = 1 * time.Second
+)
+
+// TxOptions controls how RunWith starts its transaction. The zero value uses the
+// database's default isolation level, is read-write, and retries up to
+// DefaultMaxAttempts times.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+
+	// MaxAttempts bounds the number of times the action is run when the transaction
+	// fails with a serialization failure or a deadlock. Set to 1 to disable retries.
+	MaxAttempts int
+}
+
+// RunWith is like Run but starts the transaction with the given isolation level and
+// read-only flag. If the transaction fails with a serialization failure (40001) or a
+// deadlock (40P01), the whole action is run again in a fresh transaction, with a
+// randomized exponential delay between attempts.
+//
+// Since the action may run more than once, it should not have side effects outside of
+// the transaction.
+//
+// When ctx already carries a transaction, the action simply joins it as a child scope.
+// The options are ignored in that case since the transaction has already started, and
+// retrying is left to the outermost RunWith because the entire transaction is aborted.
+func RunWith(ctx context.Context, opts TxOptions, action func(s Scope) error) (err error) {
+	if _, child := getTx(ctx); child {
+		return Run(ctx, action)
+	}
+
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultMaxAttempts
+	}
+
+	txOpts := &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+	for attempt := 1; ; attempt++ {
+		err = runTx(ctx, txOpts, action)
+		if err == nil || attempt >= attempts || !IsRetryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(retryDelay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func runTx(ctx context.Context, opts *sql.TxOptions, action func(s Scope) error) (err error) {
+	var scope scopeImpl
+	if scope, err = newScope(ctx, FromContext(ctx), opts, false); err != nil {
+		return
+	} else {
+		defer scope.End(&err)
+		return action(scope)
+	}
+}
+
+// retryDelay returns a random delay between half and the full exponential backoff value
+// for the given attempt so concurrent transactions don't retry in lockstep.
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for n := 1; n < attempt && delay < retryMaxDelay; n++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// IsRetryable reports whether err is a Postgres serialization failure or deadlock, which
+// means the transaction can be retried from the start.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	switch pgErr.Code {
+	case pgSerializationFailure, pgDeadlockDetected:
+		return true
+	default:
+		return false
+	}
+}
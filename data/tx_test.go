@@ -0,0 +1,36 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryable(t *testing.T) {
+	serialization := &pgconn.PgError{Code: "40001"}
+	deadlock := &pgconn.PgError{Code: "40P01"}
+	uniqueViolation := &pgconn.PgError{Code: "23505"}
+
+	require.True(t, IsRetryable(serialization))
+	require.True(t, IsRetryable(deadlock))
+	require.True(t, IsRetryable(fmt.Errorf("tx: %w", serialization)))
+	require.False(t, IsRetryable(uniqueViolation))
+	require.False(t, IsRetryable(errors.New("40001")))
+	require.False(t, IsRetryable(nil))
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 1; attempt < 100; attempt++ {
+		delay := retryDelay(attempt)
+		require.Greater(t, delay, time.Duration(0))
+		require.LessOrEqual(t, delay, retryMaxDelay)
+	}
+
+	first := retryDelay(1)
+	require.GreaterOrEqual(t, first, retryBaseDelay/2)
+	require.LessOrEqual(t, first, retryBaseDelay)
+}
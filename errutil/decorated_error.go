@@ -15,9 +15,16 @@ func NewCoded(code, msg string, data any) error {
 	return &decoratedErr{inner: nil, Code: code, Message: msg, Data: data}
 }
 
+// Decorate returns err with the code and data it reports, ready to be rendered as JSON.
+// Errors that are already decorated, e.g. from NewCoded, are returned as they are: wrapping
+// them again would drop their code, so render.Error would send httperrors.ErrNotFound
+// without its "not_found" and clients, fxtest's ExpectError included, could not tell
+// errors apart.
 func Decorate(err error) error {
 	if err == nil {
 		return nil
+	} else if decorated, ok := err.(*decoratedErr); ok {
+		return decorated
 	}
 
 	outerr := &decoratedErr{inner: err, Message: err.Error()}
@@ -0,0 +1,43 @@
+package errutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type codedErr struct{}
+
+func (codedErr) Error() string  { return "coded" }
+func (codedErr) Code() string   { return "coded_code" }
+func (codedErr) ErrorData() any { return map[string]any{"field": "name"} }
+
+func TestDecorate(t *testing.T) {
+	require.Nil(t, Decorate(nil))
+
+	render := func(err error) string {
+		buf, err := json.Marshal(Decorate(err))
+		require.NoError(t, err)
+		return string(buf)
+	}
+
+	require.JSONEq(t, `{"code": "", "message": "plain"}`, render(errors.New("plain")))
+	require.JSONEq(t,
+		`{"code": "coded_code", "message": "coded", "data": {"field": "name"}}`,
+		render(codedErr{}))
+
+	// errors that are already decorated keep their code and data instead of being
+	// wrapped again with neither, e.g. httperrors.ErrNotFound rendered by render.Error
+	coded := NewCoded("not_found", "not found", 42)
+	require.Same(t, coded, Decorate(coded))
+	require.JSONEq(t, `{"code": "not_found", "message": "not found", "data": 42}`, render(coded))
+	require.JSONEq(t, `{"code": "bad_request", "message": "bad"}`,
+		render(WithCode(errors.New("bad"), "bad_request")))
+
+	// wrapped ones are decorated like any other error
+	wrapped := fmt.Errorf("outer: %w", coded)
+	require.JSONEq(t, `{"code": "", "message": "outer: not found"}`, render(wrapped))
+}
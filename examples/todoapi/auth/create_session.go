@@ -2,8 +2,8 @@ package auth
 
 import (
 	"context"
-	"time"
 
+	"fx.prodigy9.co/clock"
 	"fx.prodigy9.co/data"
 	"fx.prodigy9.co/validate"
 )
@@ -54,5 +54,5 @@ func (c *CreateSession) Execute(ctx context.Context, out any) (err error) {
 	return scope.Get(out, sql,
 		user.ID,
 		token,
-		time.Now().Add(DefaultSessionAge))
+		clock.Now(ctx).Add(DefaultSessionAge))
 }
@@ -44,7 +44,7 @@ func LoadSession(cfg *config.Source) func(http.Handler) http.Handler {
 			case sess == nil:
 				render.Error(resp, req, 403, httperrors.ErrUnauthorized)
 				return
-			case sess.IsExpired():
+			case sess.IsExpired(req.Context()):
 				render.Error(resp, req, 403, ErrSessionExpired)
 				return
 			}
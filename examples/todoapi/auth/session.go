@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"time"
 
+	"fx.prodigy9.co/clock"
 	"fx.prodigy9.co/data"
 )
 
@@ -44,7 +45,7 @@ func GetSessionByToken(ctx context.Context, token string) (sess *Session, err er
 	}
 }
 
-func (s *Session) IsExpired() bool {
+func (s *Session) IsExpired(ctx context.Context) bool {
 	return !s.ExpiresAt.IsZero() &&
-		s.ExpiresAt.Before(time.Now())
+		s.ExpiresAt.Before(clock.Now(ctx))
 }
@@ -0,0 +1,87 @@
+package fxtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fx.prodigy9.co/clock"
+)
+
+// Clock is a fake clock.Clock that only moves when told to. Give it to the code under
+// test with clock.NewContext or WithClock.
+type Clock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []clockWaiter
+}
+
+type clockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+var _ clock.Clock = &Clock{}
+
+// NewClock returns a fake clock set to start, or to a fixed date if start is zero so
+// that tests do not depend on when they run.
+func NewClock(start time.Time) *Clock {
+	if start.IsZero() {
+		start = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &Clock{now: start}
+}
+
+// WithClock returns a context carrying a new fake clock set to start, see NewClock.
+func WithClock(ctx context.Context, start time.Time) (context.Context, *Clock) {
+	c := NewClock(start)
+	return clock.NewContext(ctx, c), c
+}
+
+func (c *Clock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives once the clock is advanced by at least d.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+	} else {
+		c.waiters = append(c.waiters, clockWaiter{c.now.Add(d), ch})
+	}
+	return ch
+}
+
+// Advance moves the clock forward by d, firing the After channels that are due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to t, which may be in the past.
+func (c *Clock) Set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.set(t)
+}
+
+func (c *Clock) set(t time.Time) {
+	c.now = t
+
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(t) {
+			pending = append(pending, w)
+		} else {
+			w.ch <- t
+		}
+	}
+	c.waiters = pending
+}
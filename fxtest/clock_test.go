@@ -0,0 +1,30 @@
+package fxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fx.prodigy9.co/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClock(t *testing.T) {
+	ctx, c := WithClock(context.Background(), time.Time{})
+	start := clock.Now(ctx)
+	require.Equal(t, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), start)
+
+	soon, later := c.After(time.Minute), c.After(time.Hour)
+	require.Len(t, c.After(0), 1)
+
+	c.Advance(30 * time.Second)
+	require.Empty(t, soon)
+
+	c.Advance(30 * time.Second)
+	require.Equal(t, start.Add(time.Minute), <-soon)
+	require.Empty(t, later)
+
+	c.Set(start.Add(2 * time.Hour))
+	require.Equal(t, start.Add(2*time.Hour), <-later)
+	require.Equal(t, 2*time.Hour, clock.Since(ctx, start))
+}
@@ -0,0 +1,254 @@
+package fxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"fx.prodigy9.co/config"
+	"github.com/chakrit/gendiff"
+)
+
+const (
+	GoldenDir     = "testdata"
+	GoldenExt     = ".golden"
+	goldenContext = 3
+)
+
+// DefaultScrubs are applied by every Golden call, so values that change on every run do
+// not break snapshots.
+var DefaultScrubs = []Scrub{
+	ScrubPattern(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`, "<timestamp>"),
+	ScrubPattern(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, "<uuid>"),
+}
+
+// GoldenUpdateConfig, FXTEST_UPDATE=1, does the same as the -update flag. Use it with
+// `go test ./...` when some of the packages do not import fxtest and so reject -update.
+var GoldenUpdateConfig = config.Bool("FXTEST_UPDATE")
+
+var (
+	// test packages importing fxtest must not declare an -update flag of their own
+	updateGolden = flag.Bool("update", false, "fxtest: write golden files instead of comparing with them")
+
+	updateGoldenEnv = sync.OnceValue(func() bool {
+		return config.Get(Configure(), GoldenUpdateConfig)
+	})
+)
+
+// Scrub replaces volatile values before golden comparison. Values of JSON object members
+// named in Keys are replaced entirely, at any depth. Matches of Pattern are replaced in
+// JSON strings, or anywhere in plain text.
+type Scrub struct {
+	Keys    []string
+	Pattern *regexp.Regexp
+	Replace string
+}
+
+func ScrubKeys(replace string, keys ...string) Scrub {
+	return Scrub{Keys: keys, Replace: replace}
+}
+
+func ScrubPattern(pattern, replace string) Scrub {
+	return Scrub{Pattern: regexp.MustCompile(pattern), Replace: replace}
+}
+
+// Golden compares got with the snapshot in testdata/<name>.golden and fails the test
+// with a diff if they differ. Run the tests with -update to write the current values
+// instead, e.g. `go test ./todos -run TestTodos -update`, and review the changes in git.
+//
+// got may be a string, []byte, a recorded response such as *Response or
+// *httptest.ResponseRecorder, or any value that encodes to JSON. JSON, including strings
+// holding JSON, is reindented with object keys sorted so that formatting and key order do
+// not matter. Anything else is compared as text. The DefaultScrubs and then scrubs are
+// applied before comparing:
+//
+//	resp := srv.Get("/todos").Expect(200)
+//	fxtest.Golden(t, "list_todos", resp, fxtest.ScrubKeys("<id>", "id"))
+func Golden(t *testing.T, name string, got any, scrubs ...Scrub) {
+	t.Helper()
+
+	actual, err := goldenText(got, append(slices.Clone(DefaultScrubs), scrubs...))
+	if err != nil {
+		t.Fatalf("fxtest: golden %s: %s", name, err)
+	}
+
+	path := filepath.Join(GoldenDir, name+GoldenExt)
+	if *updateGolden || updateGoldenEnv() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("fxtest: golden %s: %s", name, err)
+		} else if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("fxtest: golden %s: %s", name, err)
+		}
+		t.Logf("fxtest: updated %s", path)
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("fxtest: %s does not exist, run the test with -update to create it", path)
+	} else if err != nil {
+		t.Fatalf("fxtest: golden %s: %s", name, err)
+	}
+
+	if string(expected) != actual {
+		t.Errorf("fxtest: %s does not match, run the test with -update to accept the changes:\n%s",
+			path, goldenDiff(string(expected), actual))
+	}
+}
+
+func goldenText(got any, scrubs []Scrub) (string, error) {
+	var raw []byte
+	switch v := got.(type) {
+	case *httptest.ResponseRecorder:
+		// not Result(), which is cached and has an empty body once read
+		raw = v.Body.Bytes()
+	case *Response:
+		raw = v.Body.Bytes()
+	case interface{ Result() *http.Response }:
+		buf, err := io.ReadAll(v.Result().Body)
+		if err != nil {
+			return "", err
+		}
+		raw = buf
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	case json.RawMessage:
+		raw = v
+	default:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		raw = buf
+	}
+
+	if !json.Valid(raw) {
+		text := string(raw)
+		for _, scrub := range scrubs {
+			if scrub.Pattern != nil {
+				text = scrub.Pattern.ReplaceAllString(text, scrub.Replace)
+			}
+		}
+		return strings.TrimRight(text, "\n") + "\n", nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return "", err
+	}
+
+	// encoding/json writes map keys in sorted order
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(scrubJSON(value, scrubs)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func scrubJSON(value any, scrubs []Scrub) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = scrubJSON(item, scrubs)
+			for _, scrub := range scrubs {
+				if slices.Contains(scrub.Keys, key) {
+					v[key] = scrub.Replace
+				}
+			}
+		}
+		return v
+	case []any:
+		for idx, item := range v {
+			v[idx] = scrubJSON(item, scrubs)
+		}
+		return v
+	case string:
+		for _, scrub := range scrubs {
+			if scrub.Pattern != nil {
+				v = scrub.Pattern.ReplaceAllString(v, scrub.Replace)
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+type goldenLines struct {
+	left  []string
+	right []string
+}
+
+var _ gendiff.Interface = goldenLines{}
+
+func (d goldenLines) LeftLen() int        { return len(d.left) }
+func (d goldenLines) RightLen() int       { return len(d.right) }
+func (d goldenLines) Equal(l, r int) bool { return d.left[l] == d.right[r] }
+
+// goldenDiff is a unified-style line diff from expected to actual, with long unchanged
+// runs cut down to a few lines of context.
+func goldenDiff(expected, actual string) string {
+	var (
+		sb    = &strings.Builder{}
+		lines = goldenLines{
+			left:  strings.Split(strings.TrimRight(expected, "\n"), "\n"),
+			right: strings.Split(strings.TrimRight(actual, "\n"), "\n"),
+		}
+		diffs = gendiff.Make(lines)
+	)
+
+	sb.WriteString("--- golden\n+++ actual\n")
+	for n, d := range diffs {
+		switch d.Op {
+		case gendiff.Match:
+			start, end := d.Lstart, d.Lend
+			if n > 0 {
+				for idx := start; idx < min(end, start+goldenContext); idx++ {
+					sb.WriteString(" " + lines.left[idx] + "\n")
+				}
+				start = min(end, start+goldenContext)
+			}
+			if n < len(diffs)-1 {
+				if skip := max(start, end-goldenContext); skip > start {
+					sb.WriteString("@@ " + strconv.Itoa(skip-start) + " unchanged lines @@\n")
+					start = skip
+				}
+				for idx := start; idx < end; idx++ {
+					sb.WriteString(" " + lines.left[idx] + "\n")
+				}
+			} else if start < end {
+				sb.WriteString("@@ " + strconv.Itoa(end-start) + " unchanged lines @@\n")
+			}
+		case gendiff.Delete:
+			for idx := d.Lstart; idx < d.Lend; idx++ {
+				sb.WriteString("-" + lines.left[idx] + "\n")
+			}
+		case gendiff.Insert:
+			for idx := d.Rstart; idx < d.Rend; idx++ {
+				sb.WriteString("+" + lines.right[idx] + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
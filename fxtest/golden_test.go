@@ -0,0 +1,86 @@
+package fxtest
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoldenText(t *testing.T) {
+	scrubs := append(slices.Clone(DefaultScrubs), ScrubKeys("<id>", "id"))
+
+	text, err := goldenText(`{"b": 1, "a": {"id": 42, "at": "2025-04-01T17:19:00.123+07:00"}, "ids": ["0b9e5c3e-8a6c-4a43-9a41-2f1b4fa6a1d2"]}`, scrubs)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "a": {
+    "at": "<timestamp>",
+    "id": "<id>"
+  },
+  "b": 1,
+  "ids": [
+    "<uuid>"
+  ]
+}
+`, text)
+
+	text, err = goldenText(struct {
+		Big  int64  `json:"big"`
+		Name string `json:"name"`
+	}{9007199254740993, "todo"}, scrubs)
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"big\": 9007199254740993,\n  \"name\": \"todo\"\n}\n", text)
+
+	text, err = goldenText([]byte("Hello,\nyour code expires at 2025-04-01 17:19:00.\n\n"), scrubs)
+	require.NoError(t, err)
+	require.Equal(t, "Hello,\nyour code expires at <timestamp>.\n", text)
+}
+
+func TestGoldenDiff(t *testing.T) {
+	var expected, actual []string
+	for idx := range 20 {
+		line := "line " + string(rune('a'+idx))
+		expected, actual = append(expected, line), append(actual, line)
+	}
+	actual[10] = "changed"
+
+	require.Equal(t, `--- golden
++++ actual
+@@ 7 unchanged lines @@
+ line h
+ line i
+ line j
+-line k
++changed
+ line l
+ line m
+ line n
+@@ 6 unchanged lines @@
+`, goldenDiff(strings.Join(expected, "\n"), strings.Join(actual, "\n")))
+}
+
+func TestGolden(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	*updateGolden = true
+	t.Cleanup(func() { *updateGolden = false })
+
+	Golden(t, "nested/todo", map[string]any{"title": "test"})
+	content, err := os.ReadFile("testdata/nested/todo.golden")
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"title\": \"test\"\n}\n", string(content))
+
+	*updateGolden = false
+	Golden(t, "nested/todo", `{"title":"test"}`)
+
+	// recorders can be compared more than once, and after their Result() was read
+	rec := httptest.NewRecorder()
+	_, _ = rec.WriteString(`{ "title": "test" }`)
+	_, _ = io.ReadAll(rec.Result().Body)
+	Golden(t, "nested/todo", rec)
+	Golden(t, "nested/todo", rec)
+}
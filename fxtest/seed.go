@@ -0,0 +1,41 @@
+package fxtest
+
+import (
+	"context"
+	"testing"
+
+	"fx.prodigy9.co/config"
+	"fx.prodigy9.co/data/seed"
+)
+
+// Seed applies the named seeds, or every seed enabled for the `test` environment, to the
+// database in ctx, e.g. one from ConnectTestDatabase.
+func Seed(t *testing.T, ctx context.Context, names ...string) {
+	seeds, err := seed.LoadAuto(config.FromContext(ctx))
+	if err != nil {
+		t.Logf("fxtest: %s", err)
+		t.FailNow()
+		return
+	}
+
+	if seeds, err = seed.Select(seeds, "test", names...); err != nil {
+		t.Logf("fxtest: %s", err)
+		t.FailNow()
+	} else if err := seed.Apply(ctx, seeds...); err != nil {
+		t.Logf("fxtest: %s", err)
+		t.FailNow()
+	}
+}
+
+// SeedFile applies a single fixture file regardless of its environments, for fixtures
+// kept next to the tests using them, e.g. `fxtest.SeedFile(t, ctx, "testdata/users.yaml")`.
+func SeedFile(t *testing.T, ctx context.Context, path string) {
+	s, err := seed.LoadFile(path)
+	if err != nil {
+		t.Logf("fxtest: %s", err)
+		t.FailNow()
+	} else if err := seed.Apply(ctx, s); err != nil {
+		t.Logf("fxtest: %s", err)
+		t.FailNow()
+	}
+}
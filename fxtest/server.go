@@ -0,0 +1,182 @@
+package fxtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"fx.prodigy9.co/config"
+	"github.com/stretchr/testify/require"
+)
+
+// App is an app that NewServer can serve, implemented by *app.Builder. fxtest does not
+// import the app package so that the packages app is built from can use fxtest in their
+// own tests.
+type App interface {
+	Handler(cfg *config.Source) (http.Handler, error)
+	Migrate(ctx context.Context) error
+}
+
+// Server serves an app's HTTP fragment tree in-process for tests, see NewServer.
+type Server struct {
+	t   *testing.T
+	app App
+	ctx context.Context
+
+	once    sync.Once
+	handler http.Handler
+}
+
+// NewServer mounts the middlewares and controllers of app and its mounted fragments the
+// same way `serve` does, including the default middlewares if app has none. Requests are
+// handled through httptest without listening on a port:
+//
+//	srv := fxtest.NewServer(t, app)
+//	srv.Post("/todos", todo).Expect(201).JSON(&created)
+//	srv.Get("/todos/999").ExpectError(404, "not_found")
+//
+// Requests carry the context of ConnectTxDatabase, with the app's migrations applied, so
+// handlers use the test's config and its transaction. Use WithContext to give them
+// another context instead.
+func NewServer(t *testing.T, app App) *Server {
+	return &Server{t: t, app: app}
+}
+
+// WithContext makes requests carry the values of ctx instead, e.g. those of
+// ConnectTestDatabase, or a context with only the config of Configure for tests that
+// need no database. It must be called before the first request.
+func (s *Server) WithContext(ctx context.Context) *Server {
+	s.ctx = ctx
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	s.init()
+	return s.handler
+}
+
+func (s *Server) init() {
+	s.once.Do(func() {
+		if s.ctx == nil {
+			s.ctx = ConnectTxDatabase(s.t, s.app.Migrate)
+		}
+
+		cfg := config.FromContext(s.ctx)
+		if cfg == nil {
+			cfg = Configure()
+		}
+
+		handler, err := s.app.Handler(cfg)
+		if err != nil {
+			s.t.Logf("fxtest: %s", err)
+			s.t.FailNow()
+		}
+		s.handler = handler
+	})
+}
+
+func (s *Server) Get(path string) *Request            { return s.Request(http.MethodGet, path, nil) }
+func (s *Server) Delete(path string) *Request         { return s.Request(http.MethodDelete, path, nil) }
+func (s *Server) Post(path string, body any) *Request { return s.Request(http.MethodPost, path, body) }
+func (s *Server) Put(path string, body any) *Request  { return s.Request(http.MethodPut, path, body) }
+func (s *Server) Patch(path string, body any) *Request {
+	return s.Request(http.MethodPatch, path, body)
+}
+
+// Request starts building a request. A body that is not nil, a string, []byte or an
+// io.Reader is sent as JSON.
+func (s *Server) Request(method, path string, body any) *Request {
+	s.t.Helper()
+	s.init()
+
+	var (
+		reader      io.Reader
+		contentType string
+	)
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+	case string:
+		reader = strings.NewReader(b)
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		buf, err := json.Marshal(b)
+		require.NoError(s.t, err, "fxtest: encoding request body")
+		reader, contentType = bytes.NewReader(buf), "application/json"
+	}
+
+	req := httptest.NewRequestWithContext(s.ctx, method, path, reader)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return &Request{server: s, req: req}
+}
+
+// Request is a request being built by Server, sent by Do or one of the Expect methods.
+type Request struct {
+	server *Server
+	req    *http.Request
+}
+
+func (r *Request) Header(name, value string) *Request {
+	r.req.Header.Set(name, value)
+	return r
+}
+
+func (r *Request) Do() *Response {
+	rec := httptest.NewRecorder()
+	r.server.Handler().ServeHTTP(rec, r.req)
+	return &Response{ResponseRecorder: rec, t: r.server.t}
+}
+
+func (r *Request) Expect(status int) *Response {
+	r.server.t.Helper()
+	return r.Do().Expect(status)
+}
+
+func (r *Request) ExpectError(status int, code string) *Response {
+	r.server.t.Helper()
+	return r.Do().ExpectError(status, code)
+}
+
+// Response is a recorded response with assertion helpers that fail the test right away.
+type Response struct {
+	*httptest.ResponseRecorder
+	t *testing.T
+}
+
+// Expect checks the status code, printing the body when it doesn't match.
+func (r *Response) Expect(status int) *Response {
+	r.t.Helper()
+	require.Equal(r.t, status, r.Code, "fxtest: unexpected status, body: %s", r.Body.String())
+	return r
+}
+
+// JSON decodes the body into out.
+func (r *Response) JSON(out any) *Response {
+	r.t.Helper()
+	require.NoError(r.t, json.Unmarshal(r.Body.Bytes(), out), "fxtest: decoding body: %s", r.Body.String())
+	return r
+}
+
+// ExpectError checks the status code and the code of the errutil error rendered in the
+// body, e.g. "not_found" for httperrors.ErrNotFound.
+func (r *Response) ExpectError(status int, code string) *Response {
+	r.t.Helper()
+
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	r.Expect(status).JSON(&body)
+	require.Equal(r.t, code, body.Code, "fxtest: unexpected error code, message: %s", body.Message)
+	return r
+}
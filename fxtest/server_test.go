@@ -0,0 +1,51 @@
+package fxtest
+
+import (
+	"net/http"
+	"testing"
+
+	"fx.prodigy9.co/app"
+	"fx.prodigy9.co/config"
+	"fx.prodigy9.co/httpserver/controllers"
+	"fx.prodigy9.co/httpserver/httperrors"
+	"fx.prodigy9.co/httpserver/middlewares"
+	"fx.prodigy9.co/httpserver/render"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type echoController struct{}
+
+func (echoController) Mount(cfg *config.Source, router chi.Router) error {
+	router.Post("/echo", func(resp http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := controllers.ReadJSON(r, &body); err != nil {
+			render.Error(resp, r, 400, httperrors.ErrBadRequest)
+			return
+		}
+		body["auth"] = r.Header.Get("Authorization")
+		body["configured"] = config.FromRequest(r) != nil
+		render.JSON(resp, r, body)
+	})
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	fragment := app.Build().
+		Name("echo").
+		Middlewares(middlewares.Configure).
+		Controllers(echoController{})
+	ctx := config.NewContext(t.Context(), Configure())
+	srv := NewServer(t, app.Build().Mount(fragment)).WithContext(ctx)
+
+	var body map[string]any
+	srv.Post("/echo", map[string]any{"hello": "world"}).
+		Header("Authorization", "Bearer token").
+		Expect(200).
+		JSON(&body)
+	require.Equal(t, map[string]any{"hello": "world", "auth": "Bearer token", "configured": true}, body)
+
+	// httperrors are rendered with their codes, see errutil.Decorate
+	srv.Post("/echo", "not json").ExpectError(400, "bad_request")
+	srv.Get("/missing").ExpectError(404, "not_found")
+}
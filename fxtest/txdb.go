@@ -0,0 +1,158 @@
+package fxtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"fx.prodigy9.co/config"
+	"fx.prodigy9.co/data"
+	"fx.prodigy9.co/data/dbname"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	txDBMutex sync.Mutex
+	txDBReady bool
+	txDBUsers int
+	txDBCfg   *config.Source
+	txDB      *sqlx.DB
+	txDBErr   error
+)
+
+// ConnectTxDatabase is a faster alternative to ConnectTestDatabase. The first call in a
+// test binary creates a database shared by all the tests of the package and runs setup
+// on it, usually migrator.MigrateAuto, with a context carrying the config and database.
+// Each test then gets a context carrying its own transaction, which is rolled back when
+// the test ends so tests never see each other's data, even with t.Parallel.
+//
+//	ctx := fxtest.ConnectTxDatabase(t, migrator.MigrateAuto)
+//
+// Code under test using data.Run or data.RunWith joins the test's transaction as a child
+// scope, so commits do not really happen and AfterCommit hooks never run. Each scope
+// started from the returned context runs in a savepoint, so a statement that is expected
+// to fail, e.g. asserting a unique violation, does not abort the rest of the test's
+// transaction. Code that takes
+// its own connection from the *sqlx.DB, e.g. session-level advisory locks, does not see
+// the test's data.
+//
+// The connection to the shared database is closed when the last test using it ends. The
+// database is dropped and recreated on the first call of the next run, and left in place
+// afterwards for inspection.
+func ConnectTxDatabase(t *testing.T, setup func(ctx context.Context) error) context.Context {
+	cfg, db, err := acquireTxDatabase(t, setup)
+	if err != nil {
+		t.Logf("fxtest: %s", err)
+		t.FailNow()
+		return nil
+	}
+	t.Cleanup(releaseTxDatabase) // registered first so it runs after the scope ends
+
+	ctx := t.Context()
+	ctx = config.NewContext(ctx, cfg)
+	ctx = data.NewContext(ctx, db)
+
+	scope, err := data.NewRollbackScope(ctx, db)
+	if err != nil {
+		t.Logf("fxtest: %s", err)
+		t.FailNow()
+		return nil
+	}
+	t.Cleanup(func() {
+		var err error
+		scope.End(&err)
+	})
+	return scope.Context()
+}
+
+// acquireTxDatabase sets the shared database up on the first call, and reconnects to it
+// if it was released by all the tests that used it before.
+func acquireTxDatabase(t *testing.T, setup func(ctx context.Context) error) (*config.Source, *sqlx.DB, error) {
+	txDBMutex.Lock()
+	defer txDBMutex.Unlock()
+
+	switch {
+	case !txDBReady:
+		txDBCfg, txDB, txDBErr = setupTxDatabase(t, setup)
+		txDBReady = true
+	case txDBErr == nil && txDB == nil:
+		txDB, txDBErr = data.Connect(txDBCfg)
+	}
+	if txDBErr != nil {
+		return nil, nil, txDBErr
+	}
+
+	txDBUsers++
+	return txDBCfg, txDB, nil
+}
+
+func releaseTxDatabase() {
+	txDBMutex.Lock()
+	defer txDBMutex.Unlock()
+
+	if txDBUsers--; txDBUsers == 0 && txDB != nil {
+		_ = txDB.Close()
+		txDB = nil
+	}
+}
+
+func setupTxDatabase(t *testing.T, setup func(ctx context.Context) error) (*config.Source, *sqlx.DB, error) {
+	cfg := Configure()
+	dbURL := config.Get(cfg, data.DatabaseURLConfig)
+	if dbURL == "" {
+		return nil, nil, errors.New("DATABASE_URL is required to run tests")
+	}
+
+	name, err := dbname.From(dbURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name = txDatabaseName(name, wd)
+	if dbURL, err = dbname.Set(dbURL, name); err != nil {
+		return nil, nil, err
+	}
+	config.Set(cfg, data.DatabaseURLConfig, dbURL)
+
+	t.Log("fxtest: creating shared database", name)
+	if err := data.DropDB(cfg); err != nil && !strings.Contains(err.Error(), "does not exist") {
+		return nil, nil, err
+	} else if err := data.CreateDB(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	db, err := data.Connect(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if setup != nil {
+		ctx := config.NewContext(context.Background(), cfg)
+		ctx = data.NewContext(ctx, db)
+		if err := setup(ctx); err != nil {
+			_ = db.Close()
+			return nil, nil, fmt.Errorf("setting up %s: %w", name, err)
+		}
+	}
+	return cfg, db, nil
+}
+
+// txDatabaseName names the shared database after the package directory, with a hash of
+// its path so packages with the same name in different directories, which `go test`
+// runs concurrently, do not collide.
+func txDatabaseName(base, dir string) string {
+	sum := sha256.Sum256([]byte(dir))
+	return dbname.Sanitize(base + "_" + hex.EncodeToString(sum[:4]) + "_" + filepath.Base(dir))
+}
@@ -0,0 +1,102 @@
+package fxtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"fx.prodigy9.co/data"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTxTest(ctx context.Context) error {
+	return data.Exec(ctx, `CREATE TABLE txdb_items (name TEXT PRIMARY KEY)`)
+}
+
+func countTxItems(t *testing.T, ctx context.Context) int {
+	var n int
+	require.NoError(t, data.Get(ctx, &n, `SELECT count(*) FROM txdb_items`))
+	return n
+}
+
+func TestConnectTxDatabase_Isolation(t *testing.T) {
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			ctx := ConnectTxDatabase(t, setupTxTest)
+
+			// rows written by the other subtest were rolled back
+			require.Zero(t, countTxItems(t, ctx))
+			require.NoError(t, data.Exec(ctx, `INSERT INTO txdb_items (name) VALUES ($1)`, name))
+			require.Equal(t, 1, countTxItems(t, ctx))
+		})
+	}
+}
+
+func TestConnectTxDatabase_FailedStatement(t *testing.T) {
+	ctx := ConnectTxDatabase(t, setupTxTest)
+	require.NoError(t, data.Exec(ctx, `INSERT INTO txdb_items (name) VALUES ('a')`))
+
+	var pgErr *pgconn.PgError
+	err := data.Exec(ctx, `INSERT INTO txdb_items (name) VALUES ('a')`)
+	require.True(t, errors.As(err, &pgErr))
+	require.Equal(t, "23505", pgErr.Code)
+
+	// only the failed child scope was rolled back, its sibling's row is still there
+	err = data.Run(ctx, func(s data.Scope) error {
+		if err := s.Exec(`INSERT INTO txdb_items (name) VALUES ('b')`); err != nil {
+			return err
+		}
+		return s.Exec(`INSERT INTO txdb_items (name) VALUES ('b')`)
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, countTxItems(t, ctx))
+
+	require.NoError(t, data.Exec(ctx, `INSERT INTO txdb_items (name) VALUES ('c')`))
+	require.Equal(t, 2, countTxItems(t, ctx))
+}
+
+func TestConnectTxDatabase_NestedFailure(t *testing.T) {
+	ctx := ConnectTxDatabase(t, setupTxTest)
+
+	// a failure nested inside a child scope aborts the child's whole transaction, even
+	// if the child swallows the error, as it would in production
+	err := data.Run(ctx, func(s data.Scope) error {
+		_ = data.Exec(s.Context(), `INSERT INTO txdb_items (name) VALUES (NULL)`)
+		return s.Exec(`INSERT INTO txdb_items (name) VALUES ('a')`)
+	})
+
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr))
+	require.Equal(t, "25P02", pgErr.Code)
+
+	// the test's own transaction is unaffected
+	require.NoError(t, data.Exec(ctx, `INSERT INTO txdb_items (name) VALUES ('b')`))
+	require.Equal(t, 1, countTxItems(t, ctx))
+}
+
+func TestConnectTxDatabase_Hooks(t *testing.T) {
+	var calls []string
+	passed := t.Run("test", func(t *testing.T) {
+		ctx := ConnectTxDatabase(t, setupTxTest)
+		require.NoError(t, data.Run(ctx, func(s data.Scope) error {
+			s.AfterCommit(func() { calls = append(calls, "commit") })
+			s.AfterRollback(func() { calls = append(calls, "rollback") })
+			return nil
+		}))
+
+		// deferred to the test's transaction, which ends with the test
+		require.Empty(t, calls)
+	})
+
+	if passed {
+		require.Equal(t, []string{"rollback"}, calls)
+	}
+}
+
+func TestTxDatabaseName(t *testing.T) {
+	a := txDatabaseName("app", "/src/a/models")
+	require.Equal(t, a, txDatabaseName("app", "/src/a/models"))
+	require.NotEqual(t, a, txDatabaseName("app", "/src/b/models"))
+	require.Contains(t, a, "models")
+}
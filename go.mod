@@ -3,6 +3,7 @@ module fx.prodigy9.co
 go 1.24.2
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/chakrit/gendiff v0.0.0-20190724171441-c6e430f125ca
 	github.com/felixge/httpsnoop v1.0.4
 	github.com/getsentry/sentry-go v0.34.1
@@ -21,6 +22,7 @@ require (
 	go.jonnrb.io/vanity v0.2.0
 	golang.org/x/crypto v0.40.0
 	golang.org/x/exp v0.0.0-20250718183923-645b1fa84792
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -57,5 +59,4 @@ require (
 	golang.org/x/sys v0.34.0 // indirect
 	golang.org/x/term v0.33.0
 	golang.org/x/text v0.27.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -25,19 +25,29 @@ func NewWithFragments(cfg *config.Source, fragments []*Fragment) *Server {
 	return &Server{cfg, fragments}
 }
 
-func (s *Server) Start() error {
+// Handler mounts the server's fragments on a new router without listening, e.g. to serve
+// requests in tests with httptest.
+func (s *Server) Handler() (http.Handler, error) {
 	router := chi.NewRouter()
 
 	for _, frag := range s.fragments {
 		if err := frag.configureRoutes(s.cfg, router); err != nil {
-			return err
+			return nil, err
 		}
 	}
+	return router, nil
+}
+
+func (s *Server) Start() error {
+	handler, err := s.Handler()
+	if err != nil {
+		return err
+	}
 
 	listenAddr := config.Get(s.cfg, ListenAddrConfig)
 	srv := http.Server{
 		Addr:    listenAddr,
-		Handler: router,
+		Handler: handler,
 	}
 
 	ctrlc.Do(func() {
@@ -45,7 +55,7 @@ func (s *Server) Start() error {
 	})
 
 	fxlog.Log("listening", fxlog.String("addr", listenAddr))
-	err := srv.ListenAndServe()
+	err = srv.ListenAndServe()
 	if err == http.ErrServerClosed {
 		return nil
 	} else {
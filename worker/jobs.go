@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"fx.prodigy9.co/clock"
 	"fx.prodigy9.co/data"
 )
 
@@ -42,11 +43,15 @@ const (
 	//
 	// RANDOM() is used to randomize record selection to minimize two workers
 	// picking up the same job when there's high load.
+	//
+	// $1 is the time jobs are due by. It is NULL, i.e. the database's own clock,
+	// unless the context carries a clock, e.g. a fake one in tests, so that app
+	// servers whose clocks drift apart agree on which jobs are due.
 	FindPendingJobSQL = `
 		SELECT * FROM jobs
 		WHERE status = 'pending'
 			AND (scheduled_at IS NULL
-				OR scheduled_at < CURRENT_TIMESTAMP)
+				OR scheduled_at < COALESCE($1::timestamptz, CURRENT_TIMESTAMP))
 		ORDER BY RANDOM()
 		LIMIT 1;`
 
@@ -100,7 +105,7 @@ func findPendingJobByName(ctx context.Context, name string) (*Job, error) {
 
 func scheduleJob(ctx context.Context, name string, payload []byte, t time.Time) (*Job, error) {
 	if t.IsZero() {
-		t = time.Now()
+		t = clock.Now(ctx)
 	}
 
 	job := &Job{}
@@ -115,12 +120,18 @@ func scheduleJob(ctx context.Context, name string, payload []byte, t time.Time)
 }
 
 func takeOnePendingJob(ctx context.Context) (*Job, error) {
+	var dueBy *time.Time
+	if clock.IsSet(ctx) {
+		now := clock.Now(ctx)
+		dueBy = &now
+	}
+
 	job := &Job{}
 	err := data.Run(ctx, func(s data.Scope) error {
-		if err := s.Get(job, FindPendingJobSQL); err != nil {
+		if err := s.Get(job, FindPendingJobSQL, dueBy); err != nil {
 			return err
 		} else if err := s.Exec(UpdateJobStatusSQL,
-			RunningStatus, "", time.Now(),
+			RunningStatus, "", clock.Now(ctx),
 			job.ID, job.Status,
 		); err != nil {
 			return err
@@ -140,12 +151,12 @@ func takeOnePendingJob(ctx context.Context) (*Job, error) {
 
 func markJobAsFailed(ctx context.Context, jobId int64, reason string) error {
 	return data.Exec(ctx, UpdateJobStatusSQL,
-		FailedStatus, reason, time.Now(),
+		FailedStatus, reason, clock.Now(ctx),
 		jobId, RunningStatus)
 }
 
 func markJobAsCompleted(ctx context.Context, jobId int64) error {
 	return data.Exec(ctx, UpdateJobStatusSQL,
-		CompletedStatus, "", time.Now(),
+		CompletedStatus, "", clock.Now(ctx),
 		jobId, RunningStatus)
 }
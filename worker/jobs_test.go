@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"fx.prodigy9.co/data"
+	"fx.prodigy9.co/fxtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeOnePendingJob_Scheduled(t *testing.T) {
+	ctx := fxtest.ConnectTxDatabase(t, createJobsTable)
+	ctx, clock := fxtest.WithClock(ctx, time.Time{})
+
+	// the fake clock is far behind the database's, so the job is only due if the query
+	// compares against the clock in ctx rather than NOW()
+	scheduled, err := scheduleJob(ctx, "later", nil, clock.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	job, err := takeOnePendingJob(ctx)
+	require.NoError(t, err)
+	require.Nil(t, job)
+
+	clock.Advance(time.Hour)
+	job, err = takeOnePendingJob(ctx)
+	require.NoError(t, err)
+	require.Nil(t, job, "jobs are due strictly after scheduled_at")
+
+	clock.Advance(time.Second)
+	job, err = takeOnePendingJob(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	require.Equal(t, scheduled.ID, job.ID)
+
+	var updated Job
+	require.NoError(t, data.Get(ctx, &updated, `SELECT * FROM jobs WHERE id = $1`, job.ID))
+	require.Equal(t, RunningStatus, updated.Status)
+	require.True(t, clock.Now().Equal(updated.UpdatedAt))
+
+	job, err = takeOnePendingJob(ctx)
+	require.NoError(t, err)
+	require.Nil(t, job)
+}
+
+func TestTakeOnePendingJob_DatabaseClock(t *testing.T) {
+	ctx := fxtest.ConnectTxDatabase(t, createJobsTable)
+
+	// without a clock in ctx, jobs are due by the database's clock
+	require.NoError(t, data.Exec(ctx, `
+		INSERT INTO jobs (name, scheduled_at)
+		VALUES ('later', CURRENT_TIMESTAMP + INTERVAL '1 hour'),
+			('earlier', CURRENT_TIMESTAMP - INTERVAL '1 second')`))
+
+	job, err := takeOnePendingJob(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	require.Equal(t, "earlier", job.Name)
+
+	job, err = takeOnePendingJob(ctx)
+	require.NoError(t, err)
+	require.Nil(t, job)
+}
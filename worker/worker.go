@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"fx.prodigy9.co/clock"
 	"fx.prodigy9.co/config"
 	"fx.prodigy9.co/ctrlc"
 	"fx.prodigy9.co/data"
@@ -67,10 +68,10 @@ func New(cfg *config.Source, jobs ...Interface) *Worker {
 }
 
 func ScheduleNowIfNotExists(ctx context.Context, job Interface) (int64, error) {
-	return ScheduleAtIfNotExists(ctx, job, time.Now())
+	return ScheduleAtIfNotExists(ctx, job, clock.Now(ctx))
 }
 func ScheduleInIfNotExists(ctx context.Context, job Interface, d time.Duration) (int64, error) {
-	return ScheduleAtIfNotExists(ctx, job, time.Now().Add(d))
+	return ScheduleAtIfNotExists(ctx, job, clock.Now(ctx).Add(d))
 }
 func ScheduleAtIfNotExists(ctx context.Context, job Interface, t time.Time) (int64, error) {
 	// TODO: Might need to be careful with transactions here
@@ -83,10 +84,10 @@ func ScheduleAtIfNotExists(ctx context.Context, job Interface, t time.Time) (int
 }
 
 func ScheduleNow(ctx context.Context, job Interface) (int64, error) {
-	return ScheduleAt(ctx, job, time.Now())
+	return ScheduleAt(ctx, job, clock.Now(ctx))
 }
 func ScheduleIn(ctx context.Context, job Interface, d time.Duration) (int64, error) {
-	return ScheduleAt(ctx, job, time.Now().Add(d))
+	return ScheduleAt(ctx, job, clock.Now(ctx).Add(d))
 }
 func ScheduleAt(ctx context.Context, job Interface, t time.Time) (int64, error) {
 	fxlog.Log("scheduling",
@@ -190,7 +191,7 @@ func (w *Worker) work(ctx context.Context) {
 			select {
 			case <-ctx.Done():
 				return
-			case <-time.After(w.interval):
+			case <-clock.FromContext(ctx).After(w.interval):
 				continue
 			}
 		}
@@ -213,14 +214,14 @@ func (w *Worker) workOnce(ctx context.Context) workerSignal {
 		fxlog.String("job", job.Name),
 		fxlog.Int64("id", job.ID),
 	)
-	start := time.Now()
+	start := clock.Now(ctx)
 
 	// we got one "running" job to process
 	if err := w.processJob(ctx, job); err != nil {
 		fxlog.Log("failed",
 			fxlog.String("job", job.Name),
 			fxlog.Int64("id", job.ID),
-			fxlog.Duration("duration", time.Since(start)),
+			fxlog.Duration("duration", clock.Since(ctx, start)),
 			fxlog.Any("error", err),
 		)
 		if err := markJobAsFailed(ctx, job.ID, err.Error()); err != nil {
@@ -232,7 +233,7 @@ func (w *Worker) workOnce(ctx context.Context) workerSignal {
 		fxlog.Log("completed",
 			fxlog.String("job", job.Name),
 			fxlog.Int64("id", job.ID),
-			fxlog.Duration("duration", time.Since(start)),
+			fxlog.Duration("duration", clock.Since(ctx, start)),
 		)
 		if err := markJobAsCompleted(ctx, job.ID); err != nil {
 			w.cancel(err)